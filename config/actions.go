@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type WebhookActions []WebhookAction
 
 type WebhookAction struct {
@@ -17,3 +19,145 @@ type WorkflowHandleConfig struct {
 	Org      string `mapstructure:"org" description:"the organization where that a workflow got triggered"`
 	Workflow string `mapstructure:"workflow" description:"the id of the workflow that got triggered"`
 }
+
+type RegistrationExpiryConfig struct {
+	GracePeriod      time.Duration `mapstructure:"grace_period" description:"how long an expired registration is still accepted"`
+	ReminderDays     int           `mapstructure:"reminder_days" description:"open a renewal reminder issue this many days before a registration expires"`
+	ReminderInterval time.Duration `mapstructure:"reminder_interval" description:"how often registrations are scanned for upcoming expiry"`
+}
+
+type ContactVerificationConfig struct {
+	Enabled  bool          `mapstructure:"enabled" description:"verify that the registration contact is an active organization member or team"`
+	Failure  string        `mapstructure:"failure" description:"soft only logs departed contacts, hard rejects the registration"`
+	CacheTTL time.Duration `mapstructure:"cache_ttl" description:"how long organization members and email lookups are cached"`
+}
+
+type RegistrationSourcesConfig struct {
+	Order                   []string `mapstructure:"order" description:"registration sources consulted in order, central and/or repository"`
+	RepositoryPath          string   `mapstructure:"repository_path" description:"path of the in-repo registration file"`
+	RequireCountersignature bool     `mapstructure:"require_countersignature" description:"in-repo registrations must be listed in the central countersignature file"`
+	CountersignaturePath    string   `mapstructure:"countersignature_path" description:"file in the central repository listing countersigned repositories"`
+}
+
+type Policy struct {
+	Name       string `mapstructure:"name" description:"name of the policy, used in validation failures"`
+	Expression string `mapstructure:"expression" description:"CEL expression over event, registration and repo that has to evaluate to true"`
+	Message    string `mapstructure:"message" description:"explanation shown when the expression evaluates to false"`
+}
+
+type AllowedActionsConfig struct {
+	Interval      time.Duration          `mapstructure:"interval" description:"how often the allowed actions settings are reconciled"`
+	EventInterval time.Duration          `mapstructure:"event_interval" description:"minimum time between reconciliations of the same target triggered by webhook events"`
+	Policies      []AllowedActionsPolicy `mapstructure:"policies" description:"desired allowed actions settings per organization or repository"`
+	Enforcement   Enforcement            `mapstructure:"enforcement" description:"enforce or audit the allowed actions settings, overrides the global enforcement"`
+}
+
+type AllowedActionsPolicy struct {
+	Organization       string   `mapstructure:"organization" description:"organization the setting applies to"`
+	Repository         string   `mapstructure:"repository" description:"repository the setting applies to, the organization setting when empty"`
+	GithubOwnedAllowed bool     `mapstructure:"github_owned_allowed" description:"allow actions created by GitHub"`
+	VerifiedAllowed    bool     `mapstructure:"verified_allowed" description:"allow actions by verified creators"`
+	PatternsAllowed    []string `mapstructure:"patterns_allowed" description:"additional allowed action patterns, e.g. my-org/*"`
+}
+
+type RunnerGroupsConfig struct {
+	Interval      time.Duration     `mapstructure:"interval" description:"how often runner group access is reconciled"`
+	Organizations []string          `mapstructure:"organizations" description:"organizations whose runner groups are managed"`
+	Groups        map[string]string `mapstructure:"groups" description:"runner group granted per registration use case"`
+}
+
+type WorkflowAnalysisConfig struct {
+	Enabled       bool     `mapstructure:"enabled" description:"analyze the triggering workflow file at the head commit of workflow runs"`
+	TrustedOwners []string `mapstructure:"trusted_owners" description:"owners whose actions and reusable workflows are trusted in addition to actions, github and the organization itself"`
+	Rules         []string `mapstructure:"rules" description:"rules to apply, all rules when empty"`
+}
+
+type IssueLabel struct {
+	Name        string `mapstructure:"name" description:"label name, may contain ${{ }} expressions over the event"`
+	Color       string `mapstructure:"color" description:"hex color of the label when it is created"`
+	Description string `mapstructure:"description" description:"description of the label when it is created"`
+}
+
+type IssueTemplatesConfig struct {
+	Title     string `mapstructure:"title" description:"Go template of the issue title"`
+	Body      string `mapstructure:"body" description:"Go template of the issue body"`
+	TitleFile string `mapstructure:"title_file" description:"file in the central repository with the issue title template"`
+	BodyFile  string `mapstructure:"body_file" description:"file in the central repository with the issue body template"`
+}
+
+type ExemptionsConfig struct {
+	Entries          []Exemption   `mapstructure:"entries" description:"exemptions defined in the config file"`
+	Path             string        `mapstructure:"path" description:"file in the central repository with additional exemptions"`
+	ReminderInterval time.Duration `mapstructure:"reminder_interval" description:"how often exemptions are reloaded and checked for expiry"`
+	ReloadInterval   time.Duration `mapstructure:"reload_interval" description:"how long the exemptions of path are cached before events read them again"`
+}
+
+type Exemption struct {
+	Organization string `mapstructure:"organization" yaml:"organization" description:"organization the exemption applies to"`
+	Repository   string `mapstructure:"repository" yaml:"repository,omitempty" description:"repository the exemption applies to, all repositories when empty"`
+	Workflow     string `mapstructure:"workflow" yaml:"workflow,omitempty" description:"workflow name or path the exemption applies to, all workflows when empty"`
+	Sender       string `mapstructure:"sender" yaml:"sender,omitempty" description:"sender the exemption applies to, all senders when empty"`
+	Reason       string `mapstructure:"reason" yaml:"reason" description:"why the exemption was granted"`
+	Approver     string `mapstructure:"approver" yaml:"approver" description:"who approved the exemption"`
+	Expires      string `mapstructure:"expires" yaml:"expires" description:"last day the exemption applies, formatted as YYYY-MM-DD"`
+}
+
+type CancelRunConfig struct {
+	Enabled    bool          `mapstructure:"enabled" description:"cancel the run that triggered a failed validation"`
+	ForceAfter time.Duration `mapstructure:"force_after" description:"force cancel runs that are still not completed after this duration, disabled when empty"`
+}
+
+type CheckRunsConfig struct {
+	Enabled         bool   `mapstructure:"enabled" description:"report violations as check runs on the head commit of the run"`
+	Name            string `mapstructure:"name" description:"name of the check run"`
+	Conclusion      string `mapstructure:"conclusion" description:"failure or action_required"`
+	RegistrationURL string `mapstructure:"registration_url" description:"link to the registration instructions, the central repository when empty"`
+}
+
+type IssueTargetConfig struct {
+	Target     string `mapstructure:"target" description:"where workflow issues are filed: central, repository or both"`
+	Repository string `mapstructure:"repository" description:"central repository as owner/name, the repository of the client when empty"`
+}
+
+type DigestConfig struct {
+	Enabled       bool          `mapstructure:"enabled" description:"publish a periodic digest of the enforcement activity per organization"`
+	Interval      time.Duration `mapstructure:"interval" description:"period covered by a digest and how often it is published"`
+	Organizations []string      `mapstructure:"organizations" description:"organizations a digest is published for, the organization of the client when empty"`
+	Deliveries    []string      `mapstructure:"deliveries" description:"issue and/or notification"`
+	Contact       string        `mapstructure:"contact" description:"email address of the organization admins, used by the email notifier"`
+	ExpiringDays  int           `mapstructure:"expiring_days" description:"registrations expiring within this many days are listed"`
+	Repository    string        `mapstructure:"repository" description:"repository in each organization the digest issue is kept in, the name of the central repository when empty"`
+}
+
+type AssigneeRoutingConfig struct {
+	Enabled  bool           `mapstructure:"enabled" description:"route workflow issues to assignees by ownership instead of issue_assignees"`
+	Rules    []AssigneeRule `mapstructure:"rules" description:"routing rules, the first rule matching the repository applies"`
+	Fallback []string       `mapstructure:"fallback" description:"sources tried in order when no rule applies: registration, codeowners, org_admins and default"`
+}
+
+type AssigneeRule struct {
+	Repositories []string `mapstructure:"repositories" description:"org/repo patterns, e.g. mo-octocat/* or */flutter-*"`
+	Assignees    []string `mapstructure:"assignees" description:"users, email addresses or teams given as org/team-slug"`
+}
+
+type IssueResolutionConfig struct {
+	Interval time.Duration `mapstructure:"interval" description:"how often the repositories of open issues are validated again"`
+	Label    string        `mapstructure:"label" description:"label that replaces not-valid on closed issues"`
+}
+
+type RemediationConfig struct {
+	Enabled  bool          `mapstructure:"enabled" description:"re-enable disabled workflows once the repository is valid again"`
+	Interval time.Duration `mapstructure:"interval" description:"how often disabled workflows are checked"`
+}
+
+type EscalationConfig struct {
+	Enabled  bool              `mapstructure:"enabled" description:"escalate violations in stages instead of disabling workflows on the first failed run"`
+	Interval time.Duration     `mapstructure:"interval" description:"how often pending violations are advanced to their next stage"`
+	Stages   []EscalationStage `mapstructure:"stages" description:"the escalation ladder, ordered from the first to the terminal stage"`
+}
+
+type EscalationStage struct {
+	Action    string `mapstructure:"action" description:"notify, issue or disable"`
+	AfterDays int    `mapstructure:"after_days" description:"days since the first violation before the stage is reached"`
+	AfterRuns int    `mapstructure:"after_runs" description:"violating runs before the stage is reached"`
+}
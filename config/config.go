@@ -8,10 +8,17 @@ import (
 )
 
 type Configuration struct {
-	Clients  []Client  `json:"clients" description:"client configurations"`
-	Webhooks []Webhook `json:"webhooks" description:"webhook configurations"`
-	Repos    []Repo    `json:"repos" description:"repository configurations"`
-	Raw      []byte
+	Clients     []Client    `json:"clients" description:"client configurations"`
+	Webhooks    []Webhook   `json:"webhooks" description:"webhook configurations"`
+	Repos       []Repo      `json:"repos" description:"repository configurations"`
+	Audit       *Audit      `json:"audit" description:"audit log configuration"`
+	State       *State      `json:"state" description:"persistent controller state"`
+	Enforcement Enforcement `json:"enforcement" description:"global enforcement mode"`
+	// CircuitBreaker switches to audit mode when too many workflows are enforced at once
+	CircuitBreaker *CircuitBreaker `json:"circuit_breaker" description:"limits enforcement actions per window"`
+	Notifications  *Notifications  `json:"notifications" description:"chat and webhook notification targets"`
+	AdminToken     string          `json:"admin_token" description:"environment variable holding the token required by the admin endpoints, the audit summary is only served when set"`
+	Raw            []byte
 }
 
 type Repo struct {
@@ -40,6 +47,67 @@ type Webhook struct {
 	Actions   WebhookActions `json:"actions" description:"webhook actions"`
 }
 
+type Audit struct {
+	Path string `json:"path" description:"file the audit log is appended to, entries are only logged when empty"`
+}
+
+type State struct {
+	Path string `json:"path" description:"file the controller state is persisted to, state is kept in memory when empty"`
+}
+
+type Notifications struct {
+	Timeout string               `json:"timeout" description:"timeout of a single delivery attempt, e.g. 10s"`
+	Retries int                  `json:"retries" description:"retries after a failed delivery"`
+	Backoff string               `json:"backoff" description:"wait before the first retry, doubled for every further retry"`
+	Targets []NotificationTarget `json:"targets" description:"notification targets"`
+	Routes  []NotificationRoute  `json:"routes" description:"which notifications go to which targets, every target receives all notifications when empty"`
+	Email   *EmailNotifications  `json:"email" description:"emails to the contact of the registration, routes refer to it as email"`
+}
+
+type EmailNotifications struct {
+	Host            string   `json:"host" description:"SMTP server host"`
+	Port            int      `json:"port" description:"SMTP server port, 587 when empty"`
+	From            string   `json:"from" description:"sender address"`
+	Username        string   `json:"username" description:"SMTP user, authentication is skipped when empty"`
+	PasswordEnv     string   `json:"password_env" description:"environment variable holding the SMTP password"`
+	DisableStartTLS bool     `json:"disable_starttls" description:"send without STARTTLS, only for local relays"`
+	Subject         string   `json:"subject" description:"Go template of the subject"`
+	Body            string   `json:"body" description:"Go template of the body"`
+	Kinds           []string `json:"kinds" description:"notification kinds that are emailed, enforcement and renewal reminders when empty"`
+	RateLimit       int      `json:"rate_limit" description:"emails per recipient and rate_window"`
+	RateWindow      string   `json:"rate_window" description:"window of the rate limit, e.g. 24h"`
+}
+
+type NotificationTarget struct {
+	Name    string            `json:"name" description:"name routes refer to"`
+	Type    string            `json:"type" description:"webhook, slack or teams"`
+	URL     string            `json:"url" description:"URL notifications are posted to"`
+	URLEnv  string            `json:"url_env" description:"environment variable holding the URL, for URLs that contain a secret"`
+	Headers map[string]string `json:"headers" description:"additional HTTP headers"`
+}
+
+type NotificationRoute struct {
+	Organizations []string `json:"organizations" description:"organizations the route applies to, all when empty"`
+	Policies      []string `json:"policies" description:"violated policies the route applies to, all when empty"`
+	Kinds         []string `json:"kinds" description:"notification kinds the route applies to, e.g. disabled, all when empty"`
+	Targets       []string `json:"targets" description:"targets notified"`
+}
+
+type CircuitBreaker struct {
+	Window                string `json:"window" description:"period enforcement actions are counted in, e.g. 1h"`
+	GlobalThreshold       int    `json:"global_threshold" description:"enforcement actions per window across all organizations, unlimited when 0"`
+	OrganizationThreshold int    `json:"organization_threshold" description:"enforcement actions per window and organization, unlimited when 0"`
+	AdminToken            string `json:"admin_token" description:"environment variable holding the token required to read or reset the breaker, the global admin_token when empty"`
+}
+
+type Enforcement struct {
+	Mode          string            `json:"mode" mapstructure:"mode" description:"enforce or audit, audit only records what would have happened"`
+	Organizations map[string]string `json:"organizations" mapstructure:"organizations" description:"enforcement mode per organization"`
+	Scope         string            `json:"scope" mapstructure:"scope" description:"what is disabled on a violation: workflow, repository or organization"`
+	// ConfirmOrganization has to be set to lock down whole organizations
+	ConfirmOrganization bool `json:"confirm_organization" mapstructure:"confirm_organization" description:"confirms the organization scope"`
+}
+
 type ServerInfo struct {
 	BaseURL       string `json:"base_url"`
 	UploadURL     string `json:"upload_url"`
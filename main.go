@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/breaker"
 	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/notify"
+	"github.tools.sap/actions-rollout-app/pkg/routes"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/pkg/state"
 	"github.tools.sap/actions-rollout-app/pkg/webhooks"
+	"github.tools.sap/actions-rollout-app/pkg/webhooks/github/actions"
 	"github.tools.sap/actions-rollout-app/utils"
 
 	"github.com/go-playground/validator"
@@ -164,11 +173,53 @@ func run(opts *Opts) error {
 		return err
 	}
 
-	err = webhooks.InitWebhooks(logger, cs, globalConfig)
+	s := scheduler.New(logger.Named("scheduler"))
+
+	store, err := state.New(logger.Named("state"), globalConfig.State)
 	if err != nil {
 		return err
 	}
 
+	recorder := audit.New(logger.Named("audit"), globalConfig.Audit)
+
+	b, err := breaker.New(logger.Named("circuit-breaker"), recorder, store, globalConfig.CircuitBreaker)
+	if err != nil {
+		return err
+	}
+
+	notifier, err := notify.New(logger.Named("notify"), recorder, globalConfig.Notifications)
+	if err != nil {
+		return err
+	}
+
+	deps := &actions.Dependencies{
+		Audit:       recorder,
+		State:       store,
+		Breaker:     b,
+		Notifier:    notifier,
+		Enforcement: globalConfig.Enforcement,
+	}
+
+	err = webhooks.InitWebhooks(logger, cs, globalConfig, s, deps)
+	if err != nil {
+		return err
+	}
+
+	s.Start(context.Background())
+
+	adminToken := envToken(globalConfig.AdminToken)
+	if adminToken != "" {
+		http.HandleFunc("/audit/summary", routes.AuditSummaryHandler(deps.Audit, adminToken))
+	} else {
+		logger.Warnw("no admin token configured, the audit summary is not served", "admin_token", globalConfig.AdminToken)
+	}
+
+	breakerToken := adminToken
+	if globalConfig.CircuitBreaker != nil && globalConfig.CircuitBreaker.AdminToken != "" {
+		breakerToken = envToken(globalConfig.CircuitBreaker.AdminToken)
+	}
+	http.HandleFunc("/circuit-breaker", routes.CircuitBreakerHandler(b, breakerToken))
+
 	addr := fmt.Sprintf("%s:%d", opts.BindAddr, opts.Port)
 
 	logger.Infow("starting Actions Controller server", "version", utils.V.String(), "address", addr)
@@ -180,4 +231,12 @@ func run(opts *Opts) error {
 	return nil
 }
 
+// envToken reads the token from the environment variable, empty when no variable is configured
+func envToken(name string) string {
+	if name == "" {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
 var visitors = make(map[string]bool)
@@ -0,0 +1,200 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// Rules are all rules applied by default
+var Rules = []string{
+	utils.AnalysisRuleUnpinnedAction,
+	utils.AnalysisRulePullRequestTargetCheckout,
+	utils.AnalysisRuleWriteAllPermissions,
+	utils.AnalysisRuleSecretsInherit,
+}
+
+// defaultTrustedOwners publish first-party actions
+var defaultTrustedOwners = []string{"actions", "github"}
+
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Finding is a risky construct found in a workflow
+type Finding struct {
+	Rule    string `json:"rule"`
+	Job     string `json:"job,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	if f.Job == "" {
+		return fmt.Sprintf("%s: %s", f.Rule, f.Message)
+	}
+	return fmt.Sprintf("%s: job %s %s", f.Rule, f.Job, f.Message)
+}
+
+// Analyzer checks workflow files against the enabled rules
+type Analyzer struct {
+	rules         map[string]bool
+	trustedOwners map[string]bool
+}
+
+// New returns an analyzer for the given rules, all rules are enabled when rules is empty
+func New(rules, trustedOwners []string) (*Analyzer, error) {
+	if len(rules) == 0 {
+		rules = Rules
+	}
+
+	a := &Analyzer{rules: make(map[string]bool), trustedOwners: make(map[string]bool)}
+	for _, rule := range rules {
+		if !contains(Rules, rule) {
+			return nil, fmt.Errorf(utils.ErrUnknownAnalysisRule, rule)
+		}
+		a.rules[rule] = true
+	}
+	for _, owner := range append(append([]string{}, defaultTrustedOwners...), trustedOwners...) {
+		a.trustedOwners[strings.ToLower(owner)] = true
+	}
+	return a, nil
+}
+
+type workflow struct {
+	on          any
+	permissions any
+	jobs        map[string]map[any]any
+}
+
+// Analyze parses the workflow and returns its findings sorted by job. Actions and reusable workflows
+// of the organization the workflow belongs to are trusted.
+func (a *Analyzer) Analyze(path string, content []byte, organization string) ([]Finding, error) {
+	var raw map[any]any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf(utils.ErrParsingWorkflow, path, err)
+	}
+
+	w := workflow{permissions: raw["permissions"], jobs: make(map[string]map[any]any)}
+	// YAML 1.1 reads the unquoted on key as true
+	w.on = raw["on"]
+	if w.on == nil {
+		w.on = raw[true]
+	}
+	if jobs, ok := raw["jobs"].(map[any]any); ok {
+		for id, job := range jobs {
+			if job, ok := job.(map[any]any); ok {
+				w.jobs[fmt.Sprint(id)] = job
+			}
+		}
+	}
+
+	trusted := func(uses string) bool {
+		owner := strings.ToLower(strings.SplitN(uses, "/", 2)[0])
+		return a.trustedOwners[owner] || owner == strings.ToLower(organization)
+	}
+
+	var findings []Finding
+	if a.rules[utils.AnalysisRuleWriteAllPermissions] && w.permissions == "write-all" {
+		findings = append(findings, Finding{Rule: utils.AnalysisRuleWriteAllPermissions, Message: "grants write-all permissions to the workflow"})
+	}
+
+	pullRequestTarget := hasTrigger(w.on, "pull_request_target")
+
+	ids := make([]string, 0, len(w.jobs))
+	for id := range w.jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		job := w.jobs[id]
+
+		if a.rules[utils.AnalysisRuleWriteAllPermissions] && job["permissions"] == "write-all" {
+			findings = append(findings, Finding{Rule: utils.AnalysisRuleWriteAllPermissions, Job: id, Message: "grants write-all permissions"})
+		}
+
+		if uses, ok := job["uses"].(string); ok && !local(uses) {
+			if a.rules[utils.AnalysisRuleUnpinnedAction] && !trusted(uses) && !pinned(uses) {
+				findings = append(findings, Finding{Rule: utils.AnalysisRuleUnpinnedAction, Job: id, Message: fmt.Sprintf("calls %s which is not pinned to a commit SHA", uses)})
+			}
+			if a.rules[utils.AnalysisRuleSecretsInherit] && job["secrets"] == "inherit" && !trusted(uses) {
+				findings = append(findings, Finding{Rule: utils.AnalysisRuleSecretsInherit, Job: id, Message: fmt.Sprintf("passes all secrets to the untrusted reusable workflow %s", uses)})
+			}
+		}
+
+		steps, _ := job["steps"].([]any)
+		for i, step := range steps {
+			step, ok := step.(map[any]any)
+			if !ok {
+				continue
+			}
+			uses, _ := step["uses"].(string)
+			if uses == "" || local(uses) || strings.HasPrefix(uses, "docker://") {
+				continue
+			}
+
+			if a.rules[utils.AnalysisRuleUnpinnedAction] && !trusted(uses) && !pinned(uses) {
+				findings = append(findings, Finding{Rule: utils.AnalysisRuleUnpinnedAction, Job: id, Message: fmt.Sprintf("step %d uses %s which is not pinned to a commit SHA", i+1, uses)})
+			}
+			if a.rules[utils.AnalysisRulePullRequestTargetCheckout] && pullRequestTarget && checksOutHead(uses, step) {
+				findings = append(findings, Finding{Rule: utils.AnalysisRulePullRequestTargetCheckout, Job: id, Message: fmt.Sprintf("step %d checks out the pull request head on pull_request_target", i+1)})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// hasTrigger checks the on section, which is a single event, a list of events or a map of events
+func hasTrigger(on any, event string) bool {
+	switch on := on.(type) {
+	case string:
+		return on == event
+	case []any:
+		for _, e := range on {
+			if e == event {
+				return true
+			}
+		}
+	case map[any]any:
+		_, ok := on[event]
+		return ok
+	}
+	return false
+}
+
+// checksOutHead reports whether a checkout step fetches the untrusted head of the pull request
+func checksOutHead(uses string, step map[any]any) bool {
+	if !strings.HasPrefix(strings.ToLower(uses), "actions/checkout@") {
+		return false
+	}
+	with, _ := step["with"].(map[any]any)
+	for _, key := range []string{"ref", "repository"} {
+		value, _ := with[key].(string)
+		if strings.Contains(value, "github.event.pull_request.head") || strings.Contains(value, "github.head_ref") {
+			return true
+		}
+	}
+	return false
+}
+
+func local(uses string) bool {
+	return strings.HasPrefix(uses, "./")
+}
+
+func pinned(uses string) bool {
+	parts := strings.SplitN(uses, "@", 2)
+	return len(parts) == 2 && commitSHA.MatchString(parts[1])
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
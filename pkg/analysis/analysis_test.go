@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []string
+		workflow string
+		want     []Finding
+		wantErr  bool
+	}{
+		{
+			name: "clean workflow",
+			workflow: `
+on: push
+permissions:
+  contents: read
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+      - uses: mo-octocat/setup@v1
+      - uses: docker/login-action@465a07811f14bebb1938fbed4728c6a1ff8901fc
+      - uses: ./.github/actions/local
+      - run: make
+`,
+		},
+		{
+			name: "unpinned third-party action",
+			workflow: `
+on: [push]
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v3
+      - uses: docker/login-action@v2
+`,
+			want: []Finding{{Rule: "unpinned-action", Job: "build", Message: "step 2 uses docker/login-action@v2 which is not pinned to a commit SHA"}},
+		},
+		{
+			name: "pull_request_target with checkout of the head",
+			workflow: `
+on:
+  pull_request_target:
+    types: [opened]
+jobs:
+  test:
+    steps:
+      - uses: actions/checkout@v3
+        with:
+          ref: ${{ github.event.pull_request.head.sha }}
+`,
+			want: []Finding{{Rule: "pull-request-target-checkout", Job: "test", Message: "step 1 checks out the pull request head on pull_request_target"}},
+		},
+		{
+			name: "checkout of the head without pull_request_target",
+			workflow: `
+on: pull_request
+jobs:
+  test:
+    steps:
+      - uses: actions/checkout@v3
+        with:
+          ref: ${{ github.head_ref }}
+`,
+		},
+		{
+			name: "write-all permissions",
+			workflow: `
+on: push
+permissions: write-all
+jobs:
+  release:
+    permissions: write-all
+    steps:
+      - run: make release
+`,
+			want: []Finding{
+				{Rule: "write-all-permissions", Message: "grants write-all permissions to the workflow"},
+				{Rule: "write-all-permissions", Job: "release", Message: "grants write-all permissions"},
+			},
+		},
+		{
+			name: "secrets inherited by an untrusted reusable workflow",
+			workflow: `
+on: push
+jobs:
+  shared:
+    uses: mo-octocat/workflows/.github/workflows/build.yml@main
+    secrets: inherit
+  external:
+    uses: someone/workflows/.github/workflows/build.yml@0123456789abcdef0123456789abcdef01234567
+    secrets: inherit
+`,
+			want: []Finding{{Rule: "secrets-inherit", Job: "external", Message: "passes all secrets to the untrusted reusable workflow someone/workflows/.github/workflows/build.yml@0123456789abcdef0123456789abcdef01234567"}},
+		},
+		{
+			name:  "disabled rule",
+			rules: []string{"write-all-permissions"},
+			workflow: `
+on: push
+jobs:
+  build:
+    steps:
+      - uses: docker/login-action@v2
+`,
+		},
+		{
+			name:     "invalid workflow",
+			workflow: "on: [push",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(tt.rules, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got, err := a.Analyze(".github/workflows/ci.yml", []byte(tt.workflow), "mo-octocat")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Analyze() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Analyze() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New([]string{"unknown-rule"}, nil); err == nil {
+		t.Errorf("New() error = nil, want an error for an unknown rule")
+	}
+}
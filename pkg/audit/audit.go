@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+// Entry is a single decision taken by the controller
+type Entry struct {
+	Time         time.Time `json:"time"`
+	Organization string    `json:"organization"`
+	Repository   string    `json:"repository"`
+	WorkflowName string    `json:"workflow_name,omitempty"`
+	WorkflowID   int64     `json:"workflow_id,omitempty"`
+	Event        string    `json:"event,omitempty"`
+	Sender       string    `json:"sender,omitempty"`
+	Action       string    `json:"action"`
+	Mode         string    `json:"mode,omitempty"`
+	Valid        bool      `json:"valid"`
+	Result       any       `json:"result,omitempty"`
+}
+
+// Recorder appends entries as JSON lines to the audit log
+type Recorder struct {
+	logger *zap.SugaredLogger
+	path   string
+
+	mu sync.Mutex
+}
+
+func New(logger *zap.SugaredLogger, c *config.Audit) *Recorder {
+	r := &Recorder{logger: logger}
+	if c != nil {
+		r.path = c.Path
+	}
+	return r
+}
+
+// Enabled reports whether entries are written to the audit log and can be read back
+func (r *Recorder) Enabled() bool {
+	return r != nil && r.path != ""
+}
+
+// Record writes the entry to the audit log, entries are always logged as well
+func (r *Recorder) Record(e Entry) error {
+	if r == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	r.logger.Infow("audit", "organization", e.Organization, "repository", e.Repository, "workflow", e.WorkflowName, "action", e.Action, "valid", e.Valid)
+	if r.path == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Entries returns all entries recorded at or after since
+func (r *Recorder) Entries(since time.Time) ([]Entry, error) {
+	if r == nil || r.path == "" {
+		return nil, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := os.Open(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			r.logger.Warnw("skipping malformed audit entry", "error", err)
+			continue
+		}
+		if e.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+func TestRecorder_Entries(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		entries []Entry
+		since   time.Time
+		want    int
+	}{
+		{
+			name:    "no entries",
+			entries: nil,
+			since:   now,
+			want:    0,
+		},
+		{
+			name: "filters by time",
+			entries: []Entry{
+				{Time: now.Add(-48 * time.Hour), Organization: "mo-octocat", Repository: "old", Action: "disabled"},
+				{Time: now.Add(-time.Hour), Organization: "mo-octocat", Repository: "new", Action: "disabled"},
+				{Time: now, Organization: "mo-octocat", Repository: "new", Action: "validated", Valid: true},
+			},
+			since: now.Add(-24 * time.Hour),
+			want:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(zap.NewNop().Sugar(), &config.Audit{Path: filepath.Join(t.TempDir(), "audit.log")})
+			for _, e := range tt.entries {
+				if err := r.Record(e); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			got, err := r.Entries(tt.since)
+			if err != nil {
+				t.Fatalf("Entries() error = %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Entries() returned %d entries, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorder_withoutPath(t *testing.T) {
+	r := New(zap.NewNop().Sugar(), nil)
+	if r.Enabled() {
+		t.Error("Enabled() = true without a path")
+	}
+	if err := r.Record(Entry{Organization: "mo-octocat", Repository: "flutter-template", Action: "validated"}); err != nil {
+		t.Errorf("Record() error = %v", err)
+	}
+	entries, err := r.Entries(time.Time{})
+	if err != nil || entries != nil {
+		t.Errorf("Entries() = %v, %v, want nil, nil", entries, err)
+	}
+}
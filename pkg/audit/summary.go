@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"sort"
+	"time"
+)
+
+// RepositorySummary counts the actions taken for a single repository
+type RepositorySummary struct {
+	Actions   map[string]int `json:"actions"`
+	Workflows []string       `json:"workflows,omitempty"`
+}
+
+// OrganizationSummary aggregates the actions taken in an organization
+type OrganizationSummary struct {
+	Actions      map[string]int                `json:"actions"`
+	Repositories map[string]*RepositorySummary `json:"repositories"`
+}
+
+// Summary aggregates audit entries to review the blast radius of enforcement
+type Summary struct {
+	Since         time.Time                       `json:"since"`
+	Entries       int                             `json:"entries"`
+	Actions       map[string]int                  `json:"actions"`
+	Organizations map[string]*OrganizationSummary `json:"organizations"`
+}
+
+// Summarize aggregates entries per action, organization and repository
+func Summarize(since time.Time, entries []Entry) *Summary {
+	summary := &Summary{
+		Since:         since,
+		Entries:       len(entries),
+		Actions:       make(map[string]int),
+		Organizations: make(map[string]*OrganizationSummary),
+	}
+
+	workflows := make(map[string]map[string]bool)
+	for _, e := range entries {
+		summary.Actions[e.Action]++
+
+		org, ok := summary.Organizations[e.Organization]
+		if !ok {
+			org = &OrganizationSummary{Actions: make(map[string]int), Repositories: make(map[string]*RepositorySummary)}
+			summary.Organizations[e.Organization] = org
+		}
+		org.Actions[e.Action]++
+
+		repo, ok := org.Repositories[e.Repository]
+		if !ok {
+			repo = &RepositorySummary{Actions: make(map[string]int)}
+			org.Repositories[e.Repository] = repo
+		}
+		repo.Actions[e.Action]++
+
+		key := e.Organization + "/" + e.Repository
+		if e.WorkflowName != "" && !workflows[key][e.WorkflowName] {
+			if workflows[key] == nil {
+				workflows[key] = make(map[string]bool)
+			}
+			workflows[key][e.WorkflowName] = true
+			repo.Workflows = append(repo.Workflows, e.WorkflowName)
+			sort.Strings(repo.Workflows)
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	since := time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Organization: "mo-octocat", Repository: "flutter-template", WorkflowName: "build", Action: "would-disable"},
+		{Organization: "mo-octocat", Repository: "flutter-template", WorkflowName: "build", Action: "would-disable"},
+		{Organization: "mo-octocat", Repository: "flutter-template", WorkflowName: "ci", Action: "would-disable"},
+		{Organization: "mo-octocat", Repository: "api", Action: "validated", Valid: true},
+		{Organization: "other", Repository: "web", WorkflowName: "deploy", Action: "disabled"},
+	}
+
+	want := &Summary{
+		Since:   since,
+		Entries: 5,
+		Actions: map[string]int{"would-disable": 3, "validated": 1, "disabled": 1},
+		Organizations: map[string]*OrganizationSummary{
+			"mo-octocat": {
+				Actions: map[string]int{"would-disable": 3, "validated": 1},
+				Repositories: map[string]*RepositorySummary{
+					"flutter-template": {Actions: map[string]int{"would-disable": 3}, Workflows: []string{"build", "ci"}},
+					"api":              {Actions: map[string]int{"validated": 1}},
+				},
+			},
+			"other": {
+				Actions: map[string]int{"disabled": 1},
+				Repositories: map[string]*RepositorySummary{
+					"web": {Actions: map[string]int{"disabled": 1}, Workflows: []string{"deploy"}},
+				},
+			},
+		},
+	}
+
+	if got := Summarize(since, entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,219 @@
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/state"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// Trip is a tripped breaker, the scope is an organization or utils.CircuitBreakerGlobal
+type Trip struct {
+	Scope  string        `json:"scope"`
+	Count  int           `json:"count"`
+	Window time.Duration `json:"window"`
+	At     time.Time     `json:"at"`
+}
+
+// Breaker counts enforcement actions per window, globally and per organization. Once a threshold is
+// crossed it trips and stays open until it is reset explicitly, tripped scopes are persisted.
+type Breaker struct {
+	logger *zap.SugaredLogger
+	audit  *audit.Recorder
+	store  *state.Store
+	now    func() time.Time
+
+	window                time.Duration
+	globalThreshold       int
+	organizationThreshold int
+
+	mu      sync.Mutex
+	actions map[string][]time.Time
+	trips   map[string]*Trip
+	alerts  []func(context.Context, Trip)
+}
+
+func New(logger *zap.SugaredLogger, recorder *audit.Recorder, store *state.Store, c *config.CircuitBreaker) (*Breaker, error) {
+	b := &Breaker{
+		logger:  logger,
+		audit:   recorder,
+		store:   store,
+		now:     time.Now,
+		window:  utils.DefaultCircuitBreakerWindow,
+		actions: make(map[string][]time.Time),
+		trips:   make(map[string]*Trip),
+	}
+	if c == nil {
+		return b, nil
+	}
+
+	if c.Window != "" {
+		window, err := time.ParseDuration(c.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf(utils.ErrInvalidCircuitBreakerWindow, c.Window, err)
+		}
+		b.window = window
+	}
+	b.globalThreshold = c.GlobalThreshold
+	b.organizationThreshold = c.OrganizationThreshold
+
+	if store != nil {
+		for _, scope := range store.Keys(utils.StateBucketCircuitBreaker) {
+			trip := &Trip{}
+			if _, err := store.Get(utils.StateBucketCircuitBreaker, scope, trip); err != nil {
+				return nil, err
+			}
+			b.trips[scope] = trip
+		}
+	}
+
+	return b, nil
+}
+
+// OnTrip registers an alert that is called whenever a scope trips
+func (b *Breaker) OnTrip(alert func(context.Context, Trip)) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.alerts = append(b.alerts, alert)
+}
+
+// Tripped reports whether enforcement is paused for the organization
+func (b *Breaker) Tripped(org string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.trips[utils.CircuitBreakerGlobal] != nil || b.trips[org] != nil
+}
+
+// Allow counts an enforcement action for the organization. It returns false without counting when the
+// breaker is open or the action would cross a threshold, in which case the breaker trips.
+func (b *Breaker) Allow(ctx context.Context, org string) bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	if b.trips[utils.CircuitBreakerGlobal] != nil || b.trips[org] != nil {
+		b.mu.Unlock()
+		return false
+	}
+
+	now := b.now().UTC()
+	global := b.prune(utils.CircuitBreakerGlobal, now)
+	orgActions := b.prune(org, now)
+
+	var trip *Trip
+	switch {
+	case b.globalThreshold > 0 && global >= b.globalThreshold:
+		trip = &Trip{Scope: utils.CircuitBreakerGlobal, Count: global, Window: b.window, At: now}
+	case b.organizationThreshold > 0 && orgActions >= b.organizationThreshold:
+		trip = &Trip{Scope: org, Count: orgActions, Window: b.window, At: now}
+	default:
+		b.actions[utils.CircuitBreakerGlobal] = append(b.actions[utils.CircuitBreakerGlobal], now)
+		b.actions[org] = append(b.actions[org], now)
+		b.mu.Unlock()
+		return true
+	}
+
+	b.trips[trip.Scope] = trip
+	alerts := append([]func(context.Context, Trip){}, b.alerts...)
+	b.mu.Unlock()
+
+	b.logger.Errorw("circuit breaker tripped, enforcement switched to audit mode", "scope", trip.Scope, "count", trip.Count, "window", trip.Window)
+	if b.store != nil {
+		if err := b.store.Put(utils.StateBucketCircuitBreaker, trip.Scope, trip); err != nil {
+			b.logger.Errorw("error persisting circuit breaker", "scope", trip.Scope, "error", err)
+		}
+	}
+	b.record(trip.Scope, utils.AuditActionCircuitBreakerTripped, trip)
+	for _, alert := range alerts {
+		alert(ctx, *trip)
+	}
+	return false
+}
+
+// prune drops the actions outside the window and returns the remaining count
+func (b *Breaker) prune(scope string, now time.Time) int {
+	actions := b.actions[scope]
+	i := 0
+	for i < len(actions) && now.Sub(actions[i]) >= b.window {
+		i++
+	}
+	b.actions[scope] = actions[i:]
+	return len(b.actions[scope])
+}
+
+// Trips returns the open scopes sorted by scope
+func (b *Breaker) Trips() []Trip {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	trips := make([]Trip, 0, len(b.trips))
+	for _, trip := range b.trips {
+		trips = append(trips, *trip)
+	}
+	sort.Slice(trips, func(i, j int) bool { return trips[i].Scope < trips[j].Scope })
+	return trips
+}
+
+// Reset closes the breaker for the scope, an empty scope resets all scopes. The counters of the
+// reset scopes start over. The scopes that were open are returned.
+func (b *Breaker) Reset(scope, by string) ([]string, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	b.mu.Lock()
+	var scopes []string
+	for s := range b.trips {
+		if scope == "" || s == scope {
+			scopes = append(scopes, s)
+		}
+	}
+	for _, s := range scopes {
+		delete(b.trips, s)
+		delete(b.actions, s)
+		if s == utils.CircuitBreakerGlobal {
+			b.actions = make(map[string][]time.Time)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, s := range scopes {
+		if b.store != nil {
+			if err := b.store.Delete(utils.StateBucketCircuitBreaker, s); err != nil {
+				return nil, err
+			}
+		}
+		b.logger.Infow("circuit breaker reset", "scope", s, "by", by)
+		b.record(s, utils.AuditActionCircuitBreakerReset, map[string]string{"by": by})
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+func (b *Breaker) record(scope, action string, result any) {
+	org := scope
+	if scope == utils.CircuitBreakerGlobal {
+		org = ""
+	}
+	if err := b.audit.Record(audit.Entry{Organization: org, Action: action, Result: result}); err != nil {
+		b.logger.Errorw(utils.LoggerErrorRecordingAudit, "error", err)
+	}
+}
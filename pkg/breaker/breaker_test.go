@@ -0,0 +1,134 @@
+package breaker
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/state"
+)
+
+func TestBreaker_Allow(t *testing.T) {
+	start := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		config    *config.CircuitBreaker
+		orgs      []string
+		offsets   []time.Duration
+		want      []bool
+		wantTrips []string
+	}{
+		{
+			name:   "disabled",
+			config: nil,
+			orgs:   []string{"mo-octocat", "mo-octocat", "mo-octocat"},
+			want:   []bool{true, true, true},
+		},
+		{
+			name:      "organization threshold",
+			config:    &config.CircuitBreaker{OrganizationThreshold: 2},
+			orgs:      []string{"mo-octocat", "mo-octocat", "other", "mo-octocat"},
+			want:      []bool{true, true, true, false},
+			wantTrips: []string{"mo-octocat"},
+		},
+		{
+			name:      "global threshold",
+			config:    &config.CircuitBreaker{GlobalThreshold: 2, OrganizationThreshold: 5},
+			orgs:      []string{"mo-octocat", "other", "third", "mo-octocat"},
+			want:      []bool{true, true, false, false},
+			wantTrips: []string{"*"},
+		},
+		{
+			name:    "actions outside the window",
+			config:  &config.CircuitBreaker{Window: "1h", OrganizationThreshold: 2},
+			orgs:    []string{"mo-octocat", "mo-octocat", "mo-octocat"},
+			offsets: []time.Duration{0, 30 * time.Minute, 61 * time.Minute},
+			want:    []bool{true, true, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := New(zap.NewNop().Sugar(), nil, nil, tt.config)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			alerts := 0
+			b.OnTrip(func(context.Context, Trip) { alerts++ })
+
+			for i, org := range tt.orgs {
+				now := start
+				if tt.offsets != nil {
+					now = start.Add(tt.offsets[i])
+				}
+				b.now = func() time.Time { return now }
+				if got := b.Allow(context.Background(), org); got != tt.want[i] {
+					t.Errorf("Allow(%s) #%d = %v, want %v", org, i, got, tt.want[i])
+				}
+			}
+
+			var scopes []string
+			for _, trip := range b.Trips() {
+				scopes = append(scopes, trip.Scope)
+			}
+			if !reflect.DeepEqual(scopes, tt.wantTrips) {
+				t.Errorf("Trips() = %v, want %v", scopes, tt.wantTrips)
+			}
+			if alerts != len(tt.wantTrips) {
+				t.Errorf("alerts = %d, want %d", alerts, len(tt.wantTrips))
+			}
+		})
+	}
+}
+
+func TestBreaker_Reset(t *testing.T) {
+	c := &config.CircuitBreaker{OrganizationThreshold: 1}
+	store, err := state.New(zap.NewNop().Sugar(), &config.State{Path: filepath.Join(t.TempDir(), "state.json")})
+	if err != nil {
+		t.Fatalf("state.New() error = %v", err)
+	}
+
+	b, err := New(zap.NewNop().Sugar(), nil, store, c)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	b.Allow(context.Background(), "mo-octocat")
+	if b.Allow(context.Background(), "mo-octocat") {
+		t.Fatalf("Allow() = true, want the breaker to trip")
+	}
+
+	// a restart keeps the breaker open
+	reopened, err := New(zap.NewNop().Sugar(), nil, store, c)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !reopened.Tripped("mo-octocat") || reopened.Tripped("other") {
+		t.Fatalf("Tripped() after restart does not match the persisted trips")
+	}
+
+	scopes, err := reopened.Reset("mo-octocat", "admin")
+	if err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if !reflect.DeepEqual(scopes, []string{"mo-octocat"}) {
+		t.Errorf("Reset() = %v", scopes)
+	}
+	if reopened.Tripped("mo-octocat") || !reopened.Allow(context.Background(), "mo-octocat") {
+		t.Errorf("breaker still open after Reset()")
+	}
+	if keys := store.Keys("circuit-breaker"); len(keys) != 0 {
+		t.Errorf("persisted trips after Reset() = %v", keys)
+	}
+}
+
+func TestNew_invalidWindow(t *testing.T) {
+	if _, err := New(zap.NewNop().Sugar(), nil, nil, &config.CircuitBreaker{Window: "an hour"}); err == nil {
+		t.Errorf("New() error = nil, want an error")
+	}
+}
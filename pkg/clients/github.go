@@ -65,7 +65,7 @@ func (a *Github) initCloudClients() error {
 		return fmt.Errorf(utils.ErrMissingClient, err)
 	}
 	cloudClient := v3.NewClient(&http.Client{Transport: atr})
-	installation, _, err := cloudClient.Apps.FindRepositoryInstallation(ctx, a.organizationID, a.repository)
+	installation, err := findInstallation(ctx, cloudClient, a.organizationID, a.repository)
 	if err != nil {
 		return fmt.Errorf(utils.ErrFindingOrgInstallations, err)
 	}
@@ -86,6 +86,16 @@ func (a *Github) initCloudClients() error {
 	return nil
 }
 
+// findInstallation looks up the app installation of the repository, or of the organization when repository is empty
+func findInstallation(ctx context.Context, client *v3.Client, organization, repository string) (*v3.Installation, error) {
+	if repository == "" {
+		installation, _, err := client.Apps.FindOrganizationInstallation(ctx, organization)
+		return installation, err
+	}
+	installation, _, err := client.Apps.FindRepositoryInstallation(ctx, organization, repository)
+	return installation, err
+}
+
 func (a *Github) GetCloudV3Client() *v3.Client {
 	newClient := v3.NewClient(&http.Client{
 		Transport: &oauth2.Transport{
@@ -148,6 +158,17 @@ func (a *Github) initClients() error {
 	return nil
 }
 
+// ForRepository returns a client authenticated against the app installation of the given repository
+func (a *Github) ForRepository(organization, repository string) (*Github, error) {
+	return NewGithub(a.logger.Named(organization+"/"+repository), organization, repository, a.serverInfo, a.GetConfig())
+}
+
+// ForOrganization returns a client authenticated against the app installation of the organization, for APIs of
+// the organization itself such as runner groups and actions permissions
+func (a *Github) ForOrganization(organization string) (*Github, error) {
+	return a.ForRepository(organization, "")
+}
+
 func (a *Github) Organization() string {
 	return a.organizationID
 }
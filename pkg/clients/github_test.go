@@ -1,7 +1,11 @@
 package clients
 
 import (
+	"context"
 	"github.tools.sap/actions-rollout-app/config"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
 
@@ -177,3 +181,37 @@ func TestNewGithub(t *testing.T) {
 		})
 	}
 }
+
+func TestFindInstallation(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository string
+		wantPath   string
+	}{
+		{name: "repository", repository: "flutter-template", wantPath: "/repos/mo-octocat/flutter-template/installation"},
+		{name: "organization", repository: "", wantPath: "/orgs/mo-octocat/installation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				_, _ = w.Write([]byte(`{"id": 42}`))
+			}))
+			defer server.Close()
+
+			client := v3.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+			installation, err := findInstallation(context.Background(), client, "mo-octocat", tt.repository)
+			if err != nil {
+				t.Fatalf("findInstallation() error = %v", err)
+			}
+			if gotPath != tt.wantPath {
+				t.Errorf("findInstallation() requested %s, want %s", gotPath, tt.wantPath)
+			}
+			if installation.GetID() != 42 {
+				t.Errorf("findInstallation() id = %d, want 42", installation.GetID())
+			}
+		})
+	}
+}
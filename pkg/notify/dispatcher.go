@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// failure is recorded in the audit log once all delivery attempts to a target failed
+type failure struct {
+	Target   string `json:"target"`
+	Kind     string `json:"kind"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// Dispatcher routes notifications to their targets and retries failed deliveries
+type Dispatcher struct {
+	logger *zap.SugaredLogger
+	audit  *audit.Recorder
+
+	targets map[string]Notifier
+	names   []string
+	routes  []config.NotificationRoute
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+func New(logger *zap.SugaredLogger, recorder *audit.Recorder, c *config.Notifications) (*Dispatcher, error) {
+	d := &Dispatcher{
+		logger:  logger,
+		audit:   recorder,
+		targets: make(map[string]Notifier),
+		timeout: utils.DefaultNotificationTimeout,
+		backoff: utils.DefaultNotificationBackoff,
+	}
+	if c == nil {
+		return d, nil
+	}
+
+	var err error
+	if d.timeout, err = duration(c.Timeout, d.timeout); err != nil {
+		return nil, err
+	}
+	if d.backoff, err = duration(c.Backoff, d.backoff); err != nil {
+		return nil, err
+	}
+	if c.Retries < 0 {
+		return nil, fmt.Errorf(utils.ErrInvalidNotificationSetting, "retries", c.Retries)
+	}
+	d.retries = c.Retries
+
+	client := &http.Client{Timeout: d.timeout}
+	for _, t := range c.Targets {
+		notifier, err := newNotifier(t, client)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := d.targets[t.Name]; ok {
+			return nil, fmt.Errorf(utils.ErrInvalidNotificationTarget, t.Name, "duplicate name")
+		}
+		d.targets[t.Name] = notifier
+		d.names = append(d.names, t.Name)
+	}
+
+	if c.Email != nil {
+		email, err := NewEmail(logger.Named("email"), c.Email)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := d.targets[email.Name()]; ok {
+			return nil, fmt.Errorf(utils.ErrInvalidNotificationTarget, email.Name(), "duplicate name")
+		}
+		d.Register(email)
+	}
+
+	for i, r := range c.Routes {
+		if len(r.Targets) == 0 {
+			return nil, fmt.Errorf(utils.ErrInvalidNotificationRoute, i, "targets are required")
+		}
+		for _, target := range r.Targets {
+			if _, ok := d.targets[target]; !ok {
+				return nil, fmt.Errorf(utils.ErrInvalidNotificationRoute, i, "unknown target "+target)
+			}
+		}
+	}
+	d.routes = c.Routes
+
+	return d, nil
+}
+
+func duration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf(utils.ErrInvalidNotificationSetting, "duration", value)
+	}
+	return d, nil
+}
+
+func newNotifier(t config.NotificationTarget, client *http.Client) (Notifier, error) {
+	if t.Name == "" {
+		return nil, fmt.Errorf(utils.ErrInvalidNotificationTarget, t.Name, "name is required")
+	}
+	url := t.URL
+	if t.URLEnv != "" {
+		url = os.Getenv(t.URLEnv)
+	}
+	if url == "" {
+		return nil, fmt.Errorf(utils.ErrInvalidNotificationTarget, t.Name, "url or url_env is required")
+	}
+
+	switch t.Type {
+	case utils.NotifierWebhook:
+		return NewWebhook(t.Name, url, t.Headers, client), nil
+	case utils.NotifierSlack:
+		return NewSlack(t.Name, url, t.Headers, client), nil
+	case utils.NotifierTeams:
+		return NewTeams(t.Name, url, t.Headers, client), nil
+	}
+	return nil, fmt.Errorf(utils.ErrInvalidNotificationTarget, t.Name, "unknown type "+t.Type)
+}
+
+// Register adds a target, e.g. a notifier that is not configured through targets
+func (d *Dispatcher) Register(n Notifier) {
+	if _, ok := d.targets[n.Name()]; !ok {
+		d.names = append(d.names, n.Name())
+	}
+	d.targets[n.Name()] = n
+}
+
+// Targets returns the names of the targets the notification is routed to
+func (d *Dispatcher) Targets(n Notification) []string {
+	if d == nil {
+		return nil
+	}
+	if len(d.routes) == 0 {
+		return d.names
+	}
+
+	seen := make(map[string]bool)
+	var targets []string
+	for _, r := range d.routes {
+		if !matches(r.Organizations, n.Organization) || !matches(r.Kinds, n.Kind) || !intersects(r.Policies, n.Policies) {
+			continue
+		}
+		for _, target := range r.Targets {
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+func matches(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(values, others []string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, other := range others {
+		if matches(values, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify delivers the notification to all its targets concurrently and returns once every delivery
+// succeeded or failed for good. Failures are logged and recorded in the audit log.
+func (d *Dispatcher) Notify(ctx context.Context, n Notification) {
+	if d == nil {
+		return
+	}
+	if n.Time.IsZero() {
+		n.Time = time.Now().UTC()
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range d.Targets(n) {
+		notifier := d.targets[name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, notifier, n)
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, notifier Notifier, n Notification) {
+	backoff := d.backoff
+	var err error
+	attempts := 0
+	for attempts <= d.retries {
+		if attempts > 0 {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				d.fail(notifier, n, attempts, err)
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		attempts++
+
+		attemptCtx, cancel := context.WithTimeout(ctx, d.timeout)
+		err = notifier.Notify(attemptCtx, n)
+		cancel()
+		if err == nil {
+			return
+		}
+		d.logger.Warnw("notification attempt failed", "target", notifier.Name(), "kind", n.Kind, "attempt", attempts, "error", err)
+
+		var status *statusError
+		if errors.As(err, &status) && !status.retryable() {
+			break
+		}
+	}
+	d.fail(notifier, n, attempts, err)
+}
+
+func (d *Dispatcher) fail(notifier Notifier, n Notification, attempts int, err error) {
+	d.logger.Errorw("notification failed", "target", notifier.Name(), "kind", n.Kind, "organization", n.Organization, "repository", n.Repository, "attempts", attempts, "error", err)
+	if recordErr := d.audit.Record(audit.Entry{
+		Organization: n.Organization,
+		Repository:   n.Repository,
+		WorkflowName: n.Workflow,
+		Action:       utils.AuditActionNotificationFailed,
+		Result:       &failure{Target: notifier.Name(), Kind: n.Kind, Attempts: attempts, Error: err.Error()},
+	}); recordErr != nil {
+		d.logger.Errorw(utils.LoggerErrorRecordingAudit, "error", recordErr)
+	}
+}
@@ -0,0 +1,262 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// defaultEmailKinds are emailed when no kinds are configured
+var defaultEmailKinds = []string{
+	utils.AuditActionDisabled,
+	utils.AuditActionRepositoryDisabled,
+	utils.AuditActionOrganizationDisabled,
+	utils.NotificationKindRenewalReminder,
+	utils.NotificationKindDigest,
+}
+
+// Email sends notifications to the contact of the registration
+type Email struct {
+	logger *zap.SugaredLogger
+
+	addr      string
+	host      string
+	from      string
+	auth      smtp.Auth
+	startTLS  bool
+	tlsConfig *tls.Config
+	subject   *template.Template
+	body      *template.Template
+	kinds     map[string]bool
+	limit     int
+	window    time.Duration
+	now       func() time.Time
+
+	mu   sync.Mutex
+	sent map[string][]time.Time
+}
+
+func NewEmail(logger *zap.SugaredLogger, c *config.EmailNotifications) (*Email, error) {
+	if c.Host == "" || c.From == "" {
+		return nil, fmt.Errorf(utils.ErrInvalidEmailNotifications, "host and from are required")
+	}
+	port := c.Port
+	if port == 0 {
+		port = utils.DefaultSMTPPort
+	}
+
+	e := &Email{
+		logger:    logger,
+		addr:      net.JoinHostPort(c.Host, strconv.Itoa(port)),
+		host:      c.Host,
+		from:      c.From,
+		startTLS:  !c.DisableStartTLS,
+		tlsConfig: &tls.Config{ServerName: c.Host, MinVersion: tls.VersionTLS12},
+		kinds:     make(map[string]bool),
+		limit:     c.RateLimit,
+		now:       time.Now,
+		sent:      make(map[string][]time.Time),
+	}
+	if c.Username != "" {
+		e.auth = smtp.PlainAuth("", c.Username, os.Getenv(c.PasswordEnv), c.Host)
+	}
+	if e.limit == 0 {
+		e.limit = utils.DefaultEmailRateLimit
+	}
+	if e.limit < 0 {
+		return nil, fmt.Errorf(utils.ErrInvalidEmailNotifications, "rate_limit must not be negative")
+	}
+	window, err := duration(c.RateWindow, utils.DefaultEmailRateWindow)
+	if err != nil {
+		return nil, err
+	}
+	e.window = window
+
+	kinds := c.Kinds
+	if len(kinds) == 0 {
+		kinds = defaultEmailKinds
+	}
+	for _, kind := range kinds {
+		e.kinds[kind] = true
+	}
+
+	subject, body := c.Subject, c.Body
+	if subject == "" {
+		subject = utils.DefaultEmailSubjectTemplate
+	}
+	if body == "" {
+		body = utils.DefaultEmailBodyTemplate
+	}
+	if e.subject, err = template.New("subject").Option("missingkey=error").Parse(subject); err != nil {
+		return nil, fmt.Errorf(utils.ErrInvalidEmailNotifications, err.Error())
+	}
+	if e.body, err = template.New("body").Option("missingkey=error").Parse(body); err != nil {
+		return nil, fmt.Errorf(utils.ErrInvalidEmailNotifications, err.Error())
+	}
+	// templates referring to unknown fields fail at startup
+	if _, _, err := e.render(Notification{Kind: utils.AuditActionDisabled, Organization: "org", Repository: "repo", Contact: "team@example.com"}); err != nil {
+		return nil, fmt.Errorf(utils.ErrInvalidEmailNotifications, err.Error())
+	}
+
+	return e, nil
+}
+
+func (e *Email) Name() string {
+	return utils.NotifierEmail
+}
+
+// Notify emails the contact of the notification. Notifications without a contact, of other kinds or to a
+// recipient that reached the rate limit are skipped.
+func (e *Email) Notify(ctx context.Context, n Notification) error {
+	if n.Contact == "" || !e.kinds[n.Kind] {
+		return nil
+	}
+	address, err := mail.ParseAddress(n.Contact)
+	if err != nil {
+		e.logger.Warnw("invalid contact email, notification dropped", "recipient", n.Contact, "error", err)
+		return nil
+	}
+	recipient := strings.ToLower(address.Address)
+
+	subject, body, err := e.render(n)
+	if err != nil {
+		return err
+	}
+	at, ok := e.reserve(recipient)
+	if !ok {
+		e.logger.Warnw("email rate limit reached, notification dropped", "recipient", recipient, "kind", n.Kind, "limit", e.limit, "window", e.window)
+		return nil
+	}
+	if err := e.send(ctx, address.Address, e.message(address.Address, subject, body)); err != nil {
+		e.release(recipient, at)
+		return err
+	}
+	return nil
+}
+
+// reserve takes a slot of the recipient's limit for the window, concurrent notifications can not exceed the limit
+func (e *Email) reserve(recipient string) (time.Time, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	sent := e.sent[recipient]
+	i := 0
+	for i < len(sent) && now.Sub(sent[i]) >= e.window {
+		i++
+	}
+	e.sent[recipient] = sent[i:]
+	if len(e.sent[recipient]) >= e.limit {
+		return time.Time{}, false
+	}
+	e.sent[recipient] = append(e.sent[recipient], now)
+	return now, true
+}
+
+// release gives back a reserved slot, failed attempts do not count against the limit
+func (e *Email) release(recipient string, at time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sent := e.sent[recipient]
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].Equal(at) {
+			e.sent[recipient] = append(sent[:i:i], sent[i+1:]...)
+			return
+		}
+	}
+}
+
+func (e *Email) render(n Notification) (string, string, error) {
+	var subject, body bytes.Buffer
+	if err := e.subject.Execute(&subject, n); err != nil {
+		return "", "", err
+	}
+	if err := e.body.Execute(&body, n); err != nil {
+		return "", "", err
+	}
+	// headers must not contain line breaks
+	return strings.Join(strings.Fields(subject.String()), " "), body.String(), nil
+}
+
+func (e *Email) message(to, subject, body string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+func (e *Email) send(ctx context.Context, to string, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if e.startTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf(utils.ErrSMTPStartTLSUnsupported, e.addr)
+		}
+		if err := client.StartTLS(e.tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.auth != nil {
+		if err := client.Auth(e.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(e.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,313 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// smtpMessage is a message received by the sink
+type smtpMessage struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// smtpSink is a minimal local SMTP server that accepts every message
+type smtpSink struct {
+	listener net.Listener
+	auth     bool
+
+	mu       sync.Mutex
+	messages []smtpMessage
+}
+
+func newSMTPSink(t *testing.T, auth bool) *smtpSink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpSink{listener: listener, auth: auth}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) received() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage{}, s.messages...)
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	var msg smtpMessage
+	reply("220 localhost ESMTP sink")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO", "HELO":
+			if s.auth {
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			} else {
+				reply("250 localhost")
+			}
+		case "AUTH":
+			parts := strings.Fields(line)
+			credentials, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+			msg.auth = string(credentials)
+			reply("235 authenticated")
+		case "MAIL":
+			msg.from = line
+			reply("250 ok")
+		case "RCPT":
+			msg.to = append(msg.to, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			msg.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = smtpMessage{}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func newTestEmail(t *testing.T, c *config.EmailNotifications) *Email {
+	e, err := NewEmail(zap.NewNop().Sugar(), c)
+	if err != nil {
+		t.Fatalf("NewEmail() error = %v", err)
+	}
+	return e
+}
+
+func TestEmail_Notify(t *testing.T) {
+	t.Setenv("SMTP_PASSWORD", "hunter2")
+	s := newSMTPSink(t, true)
+	e := newTestEmail(t, &config.EmailNotifications{
+		Host:            "127.0.0.1",
+		Port:            s.port(),
+		From:            "actions-controller@example.com",
+		Username:        "controller",
+		PasswordEnv:     "SMTP_PASSWORD",
+		DisableStartTLS: true,
+	})
+
+	n := notification
+	n.Contact = "Team A <team-a@example.com>"
+	if err := e.Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	messages := s.received()
+	if len(messages) != 1 {
+		t.Fatalf("received %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.auth != "\x00controller\x00hunter2" {
+		t.Errorf("auth = %q", msg.auth)
+	}
+	if !strings.HasPrefix(msg.from, "MAIL FROM:<actions-controller@example.com>") {
+		t.Errorf("from = %q", msg.from)
+	}
+	if len(msg.to) != 1 || !strings.HasPrefix(msg.to[0], "RCPT TO:<team-a@example.com>") {
+		t.Errorf("to = %v", msg.to)
+	}
+	for _, want := range []string{
+		"To: team-a@example.com\r\n",
+		"Subject: [Actions Controller] [disabled] mo-octocat/flutter-template workflow build\r\n",
+		"the Actions Controller reports for mo-octocat/flutter-template",
+		"Details: " + notification.URL,
+	} {
+		if !strings.Contains(msg.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, msg.data)
+		}
+	}
+}
+
+func TestEmail_Notify_skipped(t *testing.T) {
+	s := newSMTPSink(t, false)
+	e := newTestEmail(t, &config.EmailNotifications{Host: "127.0.0.1", Port: s.port(), From: "bot@example.com", DisableStartTLS: true})
+
+	tests := []struct {
+		name string
+		n    Notification
+	}{
+		{name: "no contact", n: Notification{Kind: utils.AuditActionDisabled}},
+		{name: "other kind", n: Notification{Kind: utils.AuditActionExempted, Contact: "team@example.com"}},
+		{name: "invalid contact", n: Notification{Kind: utils.AuditActionDisabled, Contact: "team@example.com\r\nBcc: all@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := e.Notify(context.Background(), tt.n); err != nil {
+				t.Errorf("Notify() error = %v", err)
+			}
+		})
+	}
+	if got := len(s.received()); got != 0 {
+		t.Errorf("received %d messages, want 0", got)
+	}
+}
+
+func TestEmail_rateLimit(t *testing.T) {
+	s := newSMTPSink(t, false)
+	e := newTestEmail(t, &config.EmailNotifications{
+		Host:            "127.0.0.1",
+		Port:            s.port(),
+		From:            "bot@example.com",
+		DisableStartTLS: true,
+		RateLimit:       2,
+		RateWindow:      "1h",
+	})
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	send := func(contact string) {
+		n := Notification{Kind: utils.NotificationKindRenewalReminder, Organization: "mo-octocat", Contact: contact, Title: "renew"}
+		if err := e.Notify(context.Background(), n); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	send("team@example.com")
+	send("TEAM@example.com")
+	send("team@example.com")
+	send("other@example.com")
+	if got := len(s.received()); got != 3 {
+		t.Errorf("received %d messages, want 3", got)
+	}
+
+	now = now.Add(time.Hour)
+	send("team@example.com")
+	if got := len(s.received()); got != 4 {
+		t.Errorf("received %d messages after the window, want 4", got)
+	}
+}
+
+func TestEmail_startTLSRequired(t *testing.T) {
+	s := newSMTPSink(t, false)
+	e := newTestEmail(t, &config.EmailNotifications{Host: "127.0.0.1", Port: s.port(), From: "bot@example.com"})
+
+	err := e.Notify(context.Background(), Notification{Kind: utils.AuditActionDisabled, Contact: "team@example.com"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("Notify() error = %v, want STARTTLS unsupported", err)
+	}
+}
+
+func TestNewEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *config.EmailNotifications
+		wantErr bool
+	}{
+		{name: "defaults", config: &config.EmailNotifications{Host: "smtp.example.com", From: "bot@example.com"}},
+		{name: "missing host", config: &config.EmailNotifications{From: "bot@example.com"}, wantErr: true},
+		{name: "invalid template", config: &config.EmailNotifications{Host: "smtp.example.com", From: "bot@example.com", Body: "{{ .Unknown }}"}, wantErr: true},
+		{name: "invalid window", config: &config.EmailNotifications{Host: "smtp.example.com", From: "bot@example.com", RateWindow: "daily"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEmail(zap.NewNop().Sugar(), tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewEmail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && e.addr != net.JoinHostPort("smtp.example.com", strconv.Itoa(utils.DefaultSMTPPort)) {
+				t.Errorf("addr = %v", e.addr)
+			}
+		})
+	}
+}
+
+func TestEmail_rateLimit_concurrent(t *testing.T) {
+	s := newSMTPSink(t, false)
+	e := newTestEmail(t, &config.EmailNotifications{
+		Host:            "127.0.0.1",
+		Port:            s.port(),
+		From:            "bot@example.com",
+		DisableStartTLS: true,
+		RateLimit:       2,
+		RateWindow:      "1h",
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := Notification{Kind: utils.NotificationKindRenewalReminder, Organization: "mo-octocat", Contact: "team@example.com", Title: "renew"}
+			if err := e.Notify(context.Background(), n); err != nil {
+				t.Errorf("Notify() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(s.received()); got != 2 {
+		t.Errorf("received %d messages, want 2", got)
+	}
+}
+
+func TestEmail_rateLimit_failedSend(t *testing.T) {
+	s := newSMTPSink(t, false)
+	e := newTestEmail(t, &config.EmailNotifications{Host: "127.0.0.1", Port: s.port(), From: "bot@example.com", RateLimit: 1, RateWindow: "1h"})
+
+	if err := e.Notify(context.Background(), Notification{Kind: utils.AuditActionDisabled, Contact: "team@example.com"}); err == nil {
+		t.Fatal("Notify() error = nil, want STARTTLS unsupported")
+	}
+	if _, ok := e.reserve("team@example.com"); !ok {
+		t.Error("failed send counted against the rate limit")
+	}
+}
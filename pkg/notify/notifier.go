@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// Notification is a decision of the controller sent to chat and webhook targets
+type Notification struct {
+	Kind         string    `json:"kind"`
+	Organization string    `json:"organization"`
+	Repository   string    `json:"repository,omitempty"`
+	Workflow     string    `json:"workflow,omitempty"`
+	Policies     []string  `json:"policies,omitempty"`
+	Contact      string    `json:"contact,omitempty"`
+	Title        string    `json:"title"`
+	Text         string    `json:"text"`
+	URL          string    `json:"url,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// Notifier delivers notifications to a single target
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n Notification) error
+}
+
+// httpNotifier posts a JSON payload built from the notification
+type httpNotifier struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+	payload func(Notification) any
+}
+
+// NewWebhook posts the notification as is
+func NewWebhook(name, url string, headers map[string]string, client *http.Client) Notifier {
+	return &httpNotifier{name: name, url: url, headers: headers, client: client, payload: func(n Notification) any { return n }}
+}
+
+// NewSlack posts to Slack compatible incoming webhooks
+func NewSlack(name, url string, headers map[string]string, client *http.Client) Notifier {
+	return &httpNotifier{name: name, url: url, headers: headers, client: client, payload: slackPayload}
+}
+
+// NewTeams posts message cards to Microsoft Teams connectors
+func NewTeams(name, url string, headers map[string]string, client *http.Client) Notifier {
+	return &httpNotifier{name: name, url: url, headers: headers, client: client, payload: teamsPayload}
+}
+
+func (h *httpNotifier) Name() string {
+	return h.name
+}
+
+func (h *httpNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(h.payload(n))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range h.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{target: h.name, status: resp.StatusCode}
+	}
+	return nil
+}
+
+// statusError is returned for responses outside 2xx, only server errors and rate limits are retried
+type statusError struct {
+	target string
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf(utils.ErrNotificationStatus, e.target, e.status)
+}
+
+func (e *statusError) retryable() bool {
+	return e.status >= 500 || e.status == http.StatusTooManyRequests
+}
+
+func summary(n Notification) string {
+	var b strings.Builder
+	b.WriteString(n.Text)
+	if n.URL != "" {
+		fmt.Fprintf(&b, "\n%s", n.URL)
+	}
+	return b.String()
+}
+
+func slackPayload(n Notification) any {
+	text := fmt.Sprintf("*%s*\n%s", n.Title, n.Text)
+	if n.URL != "" {
+		text += fmt.Sprintf("\n<%s|Open in GitHub>", n.URL)
+	}
+	return map[string]any{
+		"text": summary(n),
+		"blocks": []map[string]any{{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": text},
+		}},
+	}
+}
+
+func teamsPayload(n Notification) any {
+	facts := []map[string]string{{"name": "Organization", "value": n.Organization}}
+	if n.Repository != "" {
+		facts = append(facts, map[string]string{"name": "Repository", "value": n.Repository})
+	}
+	if n.Workflow != "" {
+		facts = append(facts, map[string]string{"name": "Workflow", "value": n.Workflow})
+	}
+	if len(n.Policies) > 0 {
+		facts = append(facts, map[string]string{"name": "Policies", "value": strings.Join(n.Policies, ", ")})
+	}
+
+	card := map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    n.Title,
+		"themeColor": "CD1111",
+		"title":      n.Title,
+		"text":       n.Text,
+		"sections":   []map[string]any{{"facts": facts}},
+	}
+	if n.URL != "" {
+		card["potentialAction"] = []map[string]any{{
+			"@type":   "OpenUri",
+			"name":    "Open in GitHub",
+			"targets": []map[string]string{{"os": "default", "uri": n.URL}},
+		}}
+	}
+	return card
+}
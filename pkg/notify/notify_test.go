@@ -0,0 +1,262 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// sink is a local HTTP server that answers with the given statuses in turn and records the bodies
+type sink struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	bodies   []map[string]any
+	headers  []http.Header
+}
+
+func newSink(t *testing.T, statuses ...int) *sink {
+	s := &sink{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid payload %s: %v", body, err)
+		}
+
+		s.mu.Lock()
+		s.bodies = append(s.bodies, payload)
+		s.headers = append(s.headers, r.Header.Clone())
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *sink) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+var notification = Notification{
+	Kind:         utils.AuditActionDisabled,
+	Organization: "mo-octocat",
+	Repository:   "flutter-template",
+	Workflow:     "build",
+	Policies:     []string{"internal-only"},
+	Title:        "[disabled] mo-octocat/flutter-template workflow build",
+	Text:         "no registration found",
+	URL:          "https://octodemo.com/mo-octocat/flutter-template/actions/runs/7",
+	Time:         time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC),
+}
+
+func TestNotifiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		new   func(name, url string, headers map[string]string, client *http.Client) Notifier
+		check func(t *testing.T, payload map[string]any)
+	}{
+		{
+			name: "webhook",
+			new:  NewWebhook,
+			check: func(t *testing.T, payload map[string]any) {
+				if payload["kind"] != "disabled" || payload["repository"] != "flutter-template" || payload["url"] != notification.URL {
+					t.Errorf("payload = %v", payload)
+				}
+			},
+		},
+		{
+			name: "slack",
+			new:  NewSlack,
+			check: func(t *testing.T, payload map[string]any) {
+				blocks, ok := payload["blocks"].([]any)
+				if !ok || len(blocks) != 1 || payload["text"] != "no registration found\n"+notification.URL {
+					t.Errorf("payload = %v", payload)
+				}
+			},
+		},
+		{
+			name: "teams",
+			new:  NewTeams,
+			check: func(t *testing.T, payload map[string]any) {
+				sections, ok := payload["sections"].([]any)
+				if !ok || payload["@type"] != "MessageCard" || payload["title"] != notification.Title || len(sections) != 1 {
+					t.Errorf("payload = %v", payload)
+				}
+				facts := sections[0].(map[string]any)["facts"].([]any)
+				if len(facts) != 4 {
+					t.Errorf("facts = %v, want 4", facts)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSink(t)
+			n := tt.new(tt.name, s.URL, map[string]string{"X-Token": "secret"}, s.Client())
+			if err := n.Notify(context.Background(), notification); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			if s.requests() != 1 {
+				t.Fatalf("requests = %d, want 1", s.requests())
+			}
+			if got := s.headers[0].Get("X-Token"); got != "secret" {
+				t.Errorf("header X-Token = %q", got)
+			}
+			tt.check(t, s.bodies[0])
+		})
+	}
+}
+
+func TestDispatcher_Targets(t *testing.T) {
+	d, err := New(zap.NewNop().Sugar(), nil, &config.Notifications{
+		Targets: []config.NotificationTarget{
+			{Name: "all", Type: utils.NotifierWebhook, URL: "http://localhost"},
+			{Name: "org", Type: utils.NotifierSlack, URL: "http://localhost"},
+			{Name: "policy", Type: utils.NotifierTeams, URL: "http://localhost"},
+		},
+		Routes: []config.NotificationRoute{
+			{Targets: []string{"all"}},
+			{Organizations: []string{"mo-octocat"}, Kinds: []string{"disabled"}, Targets: []string{"org", "all"}},
+			{Policies: []string{"internal-only"}, Targets: []string{"policy"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		n    Notification
+		want []string
+	}{
+		{name: "all routes", n: notification, want: []string{"all", "org", "policy"}},
+		{name: "other organization", n: Notification{Organization: "other", Kind: "disabled"}, want: []string{"all"}},
+		{name: "other kind", n: Notification{Organization: "mo-octocat", Kind: "would-disable", Policies: []string{"internal-only"}}, want: []string{"all", "policy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.Targets(tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Targets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("SLACK_URL", "http://localhost")
+
+	tests := []struct {
+		name    string
+		config  *config.Notifications
+		wantErr bool
+	}{
+		{name: "disabled", config: nil},
+		{name: "url from env", config: &config.Notifications{Targets: []config.NotificationTarget{{Name: "slack", Type: utils.NotifierSlack, URLEnv: "SLACK_URL"}}}},
+		{name: "missing url", config: &config.Notifications{Targets: []config.NotificationTarget{{Name: "slack", Type: utils.NotifierSlack, URLEnv: "UNSET_URL"}}}, wantErr: true},
+		{name: "unknown type", config: &config.Notifications{Targets: []config.NotificationTarget{{Name: "mail", Type: "pigeon", URL: "http://localhost"}}}, wantErr: true},
+		{name: "duplicate target", config: &config.Notifications{Targets: []config.NotificationTarget{
+			{Name: "a", Type: utils.NotifierWebhook, URL: "http://localhost"},
+			{Name: "a", Type: utils.NotifierWebhook, URL: "http://localhost"},
+		}}, wantErr: true},
+		{name: "unknown route target", config: &config.Notifications{Routes: []config.NotificationRoute{{Targets: []string{"a"}}}}, wantErr: true},
+		{name: "invalid timeout", config: &config.Notifications{Timeout: "soon"}, wantErr: true},
+		{name: "negative retries", config: &config.Notifications{Retries: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(zap.NewNop().Sugar(), nil, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Notify(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantRequests int
+		wantFailure  bool
+	}{
+		{name: "delivered", statuses: nil, wantRequests: 1},
+		{name: "retried until delivered", statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests}, wantRequests: 3},
+		{name: "retries exhausted", statuses: []int{500, 500, 500, 500}, wantRequests: 3, wantFailure: true},
+		{name: "client errors are not retried", statuses: []int{http.StatusNotFound}, wantRequests: 1, wantFailure: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSink(t, tt.statuses...)
+			recorder := audit.New(zap.NewNop().Sugar(), &config.Audit{Path: filepath.Join(t.TempDir(), "audit.log")})
+			d, err := New(zap.NewNop().Sugar(), recorder, &config.Notifications{
+				Retries: 2,
+				Backoff: "1ms",
+				Targets: []config.NotificationTarget{{Name: "hook", Type: utils.NotifierWebhook, URL: s.URL}},
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			d.Notify(context.Background(), notification)
+
+			if s.requests() != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", s.requests(), tt.wantRequests)
+			}
+			entries, err := recorder.Entries(time.Time{})
+			if err != nil {
+				t.Fatalf("Entries() error = %v", err)
+			}
+			if got := len(entries) == 1 && entries[0].Action == utils.AuditActionNotificationFailed; got != tt.wantFailure {
+				t.Errorf("failure recorded = %v, want %v, entries = %+v", got, tt.wantFailure, entries)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Notify_timeout(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer s.Close()
+	defer close(release)
+
+	d, err := New(zap.NewNop().Sugar(), nil, &config.Notifications{
+		Timeout: "20ms",
+		Targets: []config.NotificationTarget{{Name: "slow", Type: utils.NotifierWebhook, URL: s.URL}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	start := time.Now()
+	d.Notify(context.Background(), notification)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Notify() took %v, want the delivery to time out", elapsed)
+	}
+}
@@ -0,0 +1,191 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// declarations is the typed environment policy expressions are checked against
+var declarations = map[string]*cel.Type{
+	"event.name":                cel.StringType,
+	"event.action":              cel.StringType,
+	"event.organization":        cel.StringType,
+	"event.repository":          cel.StringType,
+	"event.workflow_name":       cel.StringType,
+	"event.workflow_id":         cel.IntType,
+	"event.workflow_path":       cel.StringType,
+	"event.run_id":              cel.IntType,
+	"event.head_branch":         cel.StringType,
+	"event.head_sha":            cel.StringType,
+	"event.sender.login":        cel.StringType,
+	"event.sender.type":         cel.StringType,
+	"registration.url":          cel.StringType,
+	"registration.contactEmail": cel.StringType,
+	"registration.useCase":      cel.StringType,
+	"registration.repos":        cel.ListType(cel.StringType),
+	"registration.owner":        cel.StringType,
+	"registration.expires":      cel.StringType,
+	"repo.name":                 cel.StringType,
+	"repo.full_name":            cel.StringType,
+	"repo.private":              cel.BoolType,
+	"repo.visibility":           cel.StringType,
+	"repo.default_branch":       cel.StringType,
+	"repo.archived":             cel.BoolType,
+	"repo.fork":                 cel.BoolType,
+}
+
+type Sender struct {
+	Login string
+	Type  string
+}
+
+type Event struct {
+	Name         string
+	Action       string
+	Organization string
+	Repository   string
+	WorkflowName string
+	WorkflowID   int64
+	WorkflowPath string
+	RunID        int64
+	HeadBranch   string
+	HeadSHA      string
+	Sender       Sender
+}
+
+type Registration struct {
+	URL          string
+	ContactEmail string
+	UseCase      string
+	Repos        []string
+	Owner        string
+	Expires      string
+}
+
+type Repo struct {
+	Name          string
+	FullName      string
+	Private       bool
+	Visibility    string
+	DefaultBranch string
+	Archived      bool
+	Fork          bool
+}
+
+// Input is the data a policy expression is evaluated against
+type Input struct {
+	Event        Event
+	Registration Registration
+	Repo         Repo
+}
+
+func (i *Input) variables() map[string]any {
+	repos := i.Registration.Repos
+	if repos == nil {
+		repos = []string{}
+	}
+	return map[string]any{
+		"event.name":                i.Event.Name,
+		"event.action":              i.Event.Action,
+		"event.organization":        i.Event.Organization,
+		"event.repository":          i.Event.Repository,
+		"event.workflow_name":       i.Event.WorkflowName,
+		"event.workflow_id":         i.Event.WorkflowID,
+		"event.workflow_path":       i.Event.WorkflowPath,
+		"event.run_id":              i.Event.RunID,
+		"event.head_branch":         i.Event.HeadBranch,
+		"event.head_sha":            i.Event.HeadSHA,
+		"event.sender.login":        i.Event.Sender.Login,
+		"event.sender.type":         i.Event.Sender.Type,
+		"registration.url":          i.Registration.URL,
+		"registration.contactEmail": i.Registration.ContactEmail,
+		"registration.useCase":      i.Registration.UseCase,
+		"registration.repos":        repos,
+		"registration.owner":        i.Registration.Owner,
+		"registration.expires":      i.Registration.Expires,
+		"repo.name":                 i.Repo.Name,
+		"repo.full_name":            i.Repo.FullName,
+		"repo.private":              i.Repo.Private,
+		"repo.visibility":           i.Repo.Visibility,
+		"repo.default_branch":       i.Repo.DefaultBranch,
+		"repo.archived":             i.Repo.Archived,
+		"repo.fork":                 i.Repo.Fork,
+	}
+}
+
+type policy struct {
+	name    string
+	message string
+	program cel.Program
+}
+
+// Engine evaluates compiled policy expressions
+type Engine struct {
+	policies []policy
+}
+
+// New compiles and type checks the policies, every expression has to evaluate to a bool
+func New(policies []config.Policy) (*Engine, error) {
+	var opts []cel.EnvOption
+	for name, t := range declarations {
+		opts = append(opts, cel.Variable(name, t))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := &Engine{}
+	for _, p := range policies {
+		if p.Name == "" {
+			return nil, fmt.Errorf(utils.ErrInvalidPolicy, p.Expression, "name is empty")
+		}
+
+		ast, issues := env.Compile(p.Expression)
+		if issues.Err() != nil {
+			return nil, fmt.Errorf(utils.ErrInvalidPolicy, p.Name, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf(utils.ErrInvalidPolicy, p.Name, "expression does not evaluate to bool")
+		}
+
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf(utils.ErrInvalidPolicy, p.Name, err)
+		}
+
+		message := p.Message
+		if message == "" {
+			message = p.Expression
+		}
+		engine.policies = append(engine.policies, policy{name: p.Name, message: message, program: program})
+	}
+
+	return engine, nil
+}
+
+// Evaluate returns a failure for every policy the input does not satisfy
+func (e *Engine) Evaluate(input *Input) ([]string, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	vars := input.variables()
+
+	var failures []string
+	for _, p := range e.policies {
+		out, _, err := p.program.Eval(vars)
+		if err != nil {
+			return nil, fmt.Errorf(utils.ErrEvaluatingPolicy, p.name, err)
+		}
+		if allowed, ok := out.Value().(bool); !ok || !allowed {
+			failures = append(failures, fmt.Sprintf("policy %s: %s", p.name, p.message))
+		}
+	}
+
+	return failures, nil
+}
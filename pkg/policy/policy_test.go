@@ -0,0 +1,103 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []config.Policy
+		wantErr  bool
+	}{
+		{
+			name: "valid expression",
+			policies: []config.Policy{
+				{Name: "no-bots", Expression: "event.sender.type != 'Bot' && registration.useCase in ['ci','release']"},
+			},
+		},
+		{
+			name:     "unknown field",
+			policies: []config.Policy{{Name: "typo", Expression: "event.sender.typo == 'Bot'"}},
+			wantErr:  true,
+		},
+		{
+			name:     "type mismatch",
+			policies: []config.Policy{{Name: "mismatch", Expression: "event.workflow_id == 'abc'"}},
+			wantErr:  true,
+		},
+		{
+			name:     "not a bool",
+			policies: []config.Policy{{Name: "string", Expression: "registration.useCase"}},
+			wantErr:  true,
+		},
+		{
+			name:     "syntax error",
+			policies: []config.Policy{{Name: "syntax", Expression: "repo.private &&"}},
+			wantErr:  true,
+		},
+		{
+			name:     "missing name",
+			policies: []config.Policy{{Expression: "repo.private"}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.policies); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine, err := New([]config.Policy{
+		{Name: "no-bots", Expression: "event.sender.type != 'Bot' && registration.useCase in ['ci','release']", Message: "bots may only run ci or release workflows"},
+		{Name: "private", Expression: "repo.private || size(registration.repos) > 0"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input *Input
+		want  []string
+	}{
+		{
+			name: "all policies hold",
+			input: &Input{
+				Event:        Event{Sender: Sender{Login: "octocat", Type: "User"}},
+				Registration: Registration{UseCase: "ci"},
+				Repo:         Repo{Private: true},
+			},
+			want: nil,
+		},
+		{
+			name: "policies violated",
+			input: &Input{
+				Event:        Event{Sender: Sender{Login: "dependabot", Type: "Bot"}},
+				Registration: Registration{UseCase: "ci"},
+			},
+			want: []string{
+				"policy no-bots: bots may only run ci or release workflows",
+				"policy private: repo.private || size(registration.repos) > 0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := engine.Evaluate(tt.input)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
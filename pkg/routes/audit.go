@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+)
+
+// AuditSummaryHandler serves the aggregated audit log, the period is set with ?since=<duration> (default 24h).
+// It requires the admin token as bearer token.
+func AuditSummaryHandler(recorder *audit.Recorder, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		period := 24 * time.Hour
+		if s := r.URL.Query().Get("since"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			period = d
+		}
+
+		since := time.Now().UTC().Add(-period)
+		entries, err := recorder.Entries(since)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(audit.Summarize(since, entries))
+	}
+}
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+)
+
+func TestAuditSummaryHandler(t *testing.T) {
+	recorder := audit.New(zap.NewNop().Sugar(), &config.Audit{Path: filepath.Join(t.TempDir(), "audit.log")})
+	if err := recorder.Record(audit.Entry{Organization: "mo-octocat", Repository: "flutter-template", Action: "would-disable"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		auth       string
+		wantStatus int
+		wantCount  int
+	}{
+		{name: "default period", target: "/audit/summary", auth: "Bearer secret", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "custom period", target: "/audit/summary?since=1h", auth: "Bearer secret", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "invalid period", target: "/audit/summary?since=yesterday", auth: "Bearer secret", wantStatus: http.StatusBadRequest},
+		{name: "without token", target: "/audit/summary", wantStatus: http.StatusForbidden},
+		{name: "wrong token", target: "/audit/summary", auth: "Bearer guess", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rr := httptest.NewRecorder()
+			AuditSummaryHandler(recorder, "secret").ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var summary audit.Summary
+			if err := json.Unmarshal(rr.Body.Bytes(), &summary); err != nil {
+				t.Fatal(err)
+			}
+			if summary.Actions["would-disable"] != tt.wantCount {
+				t.Errorf("handler returned %d would-disable entries, want %d", summary.Actions["would-disable"], tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.tools.sap/actions-rollout-app/pkg/breaker"
+)
+
+// CircuitBreakerHandler lists the open circuit breaker scopes on GET. POST resets the breaker for ?scope=<organization|*>,
+// or for all scopes without a scope. Both require the admin token as bearer token.
+func CircuitBreakerHandler(b *breaker.Breaker, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorized(r, token) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, map[string]any{"trips": b.Trips()})
+		case http.MethodPost:
+			scopes, err := b.Reset(r.URL.Query().Get("scope"), r.RemoteAddr)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, map[string]any{"reset": scopes})
+		}
+	}
+}
+
+// authorized compares the bearer token of the request with the admin token, requests are rejected without a token
+func authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(v)
+}
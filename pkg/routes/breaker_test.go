@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/breaker"
+)
+
+func TestCircuitBreakerHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		token       string
+		auth        string
+		wantStatus  int
+		wantTripped bool
+	}{
+		{name: "status", method: http.MethodGet, target: "/circuit-breaker", token: "secret", auth: "Bearer secret", wantStatus: http.StatusOK, wantTripped: true},
+		{name: "status without token", method: http.MethodGet, target: "/circuit-breaker", token: "secret", wantStatus: http.StatusForbidden, wantTripped: true},
+		{name: "reset without token", method: http.MethodPost, target: "/circuit-breaker", token: "secret", wantStatus: http.StatusForbidden, wantTripped: true},
+		{name: "reset with wrong token", method: http.MethodPost, target: "/circuit-breaker", token: "secret", auth: "Bearer guess", wantStatus: http.StatusForbidden, wantTripped: true},
+		{name: "reset disabled", method: http.MethodPost, target: "/circuit-breaker", auth: "Bearer ", wantStatus: http.StatusForbidden, wantTripped: true},
+		{name: "reset other scope", method: http.MethodPost, target: "/circuit-breaker?scope=other", token: "secret", auth: "Bearer secret", wantStatus: http.StatusOK, wantTripped: true},
+		{name: "reset", method: http.MethodPost, target: "/circuit-breaker?scope=mo-octocat", token: "secret", auth: "Bearer secret", wantStatus: http.StatusOK},
+		{name: "unsupported method", method: http.MethodDelete, target: "/circuit-breaker", token: "secret", wantStatus: http.StatusMethodNotAllowed, wantTripped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := breaker.New(zap.NewNop().Sugar(), nil, nil, &config.CircuitBreaker{OrganizationThreshold: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.Allow(context.Background(), "mo-octocat")
+			b.Allow(context.Background(), "mo-octocat")
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rr := httptest.NewRecorder()
+			CircuitBreakerHandler(b, tt.token).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if got := b.Tripped("mo-octocat"); got != tt.wantTripped {
+				t.Errorf("Tripped() = %v, want %v", got, tt.wantTripped)
+			}
+		})
+	}
+}
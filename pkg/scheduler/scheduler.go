@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// Job is a task that is executed periodically by the Scheduler
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	logger *zap.SugaredLogger
+
+	mu      sync.Mutex
+	jobs    []Job
+	started bool
+	ctx     context.Context
+}
+
+func New(logger *zap.SugaredLogger) *Scheduler {
+	return &Scheduler{
+		logger: logger,
+	}
+}
+
+// Add registers jobs with the scheduler. Jobs added after Start are started immediately.
+func (s *Scheduler) Add(jobs ...Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range jobs {
+		if job.Interval <= 0 || job.Run == nil {
+			s.logger.Warnw(utils.LoggerWarnInvalidJob, "job", job.Name)
+			continue
+		}
+		s.jobs = append(s.jobs, job)
+		if s.started {
+			go s.loop(s.ctx, job)
+		}
+	}
+}
+
+// Start runs every registered job once and then on its interval until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+	s.ctx = ctx
+
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	ctx, cancel := context.WithTimeout(ctx, utils.ScheduledJobTimeout)
+	defer cancel()
+
+	s.logger.Debugw(utils.LoggerDebugRunningJob, "job", job.Name)
+	if err := job.Run(ctx); err != nil {
+		s.logger.Errorw(utils.LoggerErrorRunningJob, "job", job.Name, "error", err)
+	}
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestScheduler_Start(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      func(counter *int32) Job
+		minRuns  int32
+		wantRuns bool
+	}{
+		{
+			name: "runs job repeatedly",
+			job: func(counter *int32) Job {
+				return Job{Name: "count", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+					atomic.AddInt32(counter, 1)
+					return nil
+				}}
+			},
+			minRuns:  2,
+			wantRuns: true,
+		},
+		{
+			name: "keeps running after errors",
+			job: func(counter *int32) Job {
+				return Job{Name: "failing", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+					atomic.AddInt32(counter, 1)
+					return errors.New("boom")
+				}}
+			},
+			minRuns:  2,
+			wantRuns: true,
+		},
+		{
+			name: "skips job without interval",
+			job: func(counter *int32) Job {
+				return Job{Name: "invalid", Run: func(ctx context.Context) error {
+					atomic.AddInt32(counter, 1)
+					return nil
+				}}
+			},
+			wantRuns: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var counter int32
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			s := New(zap.NewNop().Sugar())
+			s.Add(tt.job(&counter))
+			s.Start(ctx)
+
+			time.Sleep(100 * time.Millisecond)
+			got := atomic.LoadInt32(&counter)
+			if tt.wantRuns && got < tt.minRuns {
+				t.Errorf("Start() ran job %d times, want at least %d", got, tt.minRuns)
+			}
+			if !tt.wantRuns && got != 0 {
+				t.Errorf("Start() ran job %d times, want 0", got)
+			}
+		})
+	}
+}
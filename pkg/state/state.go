@@ -0,0 +1,132 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+// Store is a key value store grouped in buckets. It is persisted as a JSON file so that state
+// survives restarts; without a path it is only kept in memory.
+type Store struct {
+	logger *zap.SugaredLogger
+	path   string
+
+	mu      sync.Mutex
+	buckets map[string]map[string]json.RawMessage
+}
+
+func New(logger *zap.SugaredLogger, c *config.State) (*Store, error) {
+	s := &Store{
+		logger:  logger,
+		buckets: make(map[string]map[string]json.RawMessage),
+	}
+	if c == nil || c.Path == "" {
+		return s, nil
+	}
+	s.path = c.Path
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.buckets); err != nil {
+		return nil, err
+	}
+	if s.buckets == nil {
+		s.buckets = make(map[string]map[string]json.RawMessage)
+	}
+
+	return s, nil
+}
+
+// Get decodes the value stored under key into out, ok is false when the key does not exist
+func (s *Store) Get(bucket, key string, out any) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.buckets[bucket][key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, out)
+}
+
+// Put stores value under key and persists the store
+func (s *Store) Put(bucket, key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string]json.RawMessage)
+	}
+	s.buckets[bucket][key] = raw
+	return s.persist()
+}
+
+// Delete removes key from the bucket and persists the store
+func (s *Store) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucket][key]; !ok {
+		return nil
+	}
+	delete(s.buckets[bucket], key)
+	return s.persist()
+}
+
+// Keys returns the sorted keys of a bucket
+func (s *Store) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Store) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.buckets)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+type record struct {
+	Name string
+	Runs int
+}
+
+func TestStore_persistence(t *testing.T) {
+	c := &config.State{Path: filepath.Join(t.TempDir(), "state.json")}
+
+	s, err := New(zap.NewNop().Sugar(), c)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := s.Put("violations", "mo-octocat/flutter-template", record{Name: "build", Runs: 2}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Put("violations", "mo-octocat/api", record{Name: "ci", Runs: 1}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Delete("violations", "mo-octocat/api"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	reopened, err := New(zap.NewNop().Sugar(), c)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if got := reopened.Keys("violations"); !reflect.DeepEqual(got, []string{"mo-octocat/flutter-template"}) {
+		t.Errorf("Keys() = %v", got)
+	}
+
+	var got record
+	ok, err := reopened.Get("violations", "mo-octocat/flutter-template", &got)
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if !reflect.DeepEqual(got, record{Name: "build", Runs: 2}) {
+		t.Errorf("Get() = %v", got)
+	}
+}
+
+func TestStore_Get(t *testing.T) {
+	tests := []struct {
+		name   string
+		bucket string
+		key    string
+		want   bool
+	}{
+		{name: "existing key", bucket: "violations", key: "mo-octocat/flutter-template", want: true},
+		{name: "missing key", bucket: "violations", key: "mo-octocat/api", want: false},
+		{name: "missing bucket", bucket: "disabled", key: "mo-octocat/flutter-template", want: false},
+	}
+
+	s, err := New(zap.NewNop().Sugar(), nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := s.Put("violations", "mo-octocat/flutter-template", record{Name: "build"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r record
+			got, err := s.Get(tt.bucket, tt.key, &r)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
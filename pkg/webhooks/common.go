@@ -5,18 +5,21 @@ import (
 
 	"github.tools.sap/actions-rollout-app/config"
 	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
 	"github.tools.sap/actions-rollout-app/pkg/webhooks/github"
+	"github.tools.sap/actions-rollout-app/pkg/webhooks/github/actions"
 
 	"go.uber.org/zap"
 )
 
-func InitWebhooks(logger *zap.SugaredLogger, cs clients.ClientMap, c *config.Configuration) error {
+func InitWebhooks(logger *zap.SugaredLogger, cs clients.ClientMap, c *config.Configuration, s *scheduler.Scheduler, deps *actions.Dependencies) error {
 	for _, w := range c.Webhooks {
-		controller, err := github.NewGithubWebhook(logger.Named("github-webhook"), w, cs)
+		controller, err := github.NewGithubWebhook(logger.Named("github-webhook"), w, cs, deps)
 		if err != nil {
 			return err
 		}
 		http.HandleFunc(w.ServePath, controller.Handle)
+		s.Add(controller.Jobs()...)
 		logger.Infow("initialized github webhook", "serve-path", w.ServePath)
 	}
 	return nil
@@ -3,6 +3,8 @@ package webhooks
 import (
 	"github.tools.sap/actions-rollout-app/config"
 	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/pkg/webhooks/github/actions"
 	"go.uber.org/zap"
 	"testing"
 )
@@ -12,6 +14,8 @@ func TestInitWebhooks(t *testing.T) {
 		logger *zap.SugaredLogger
 		cs     clients.ClientMap
 		c      *config.Configuration
+		s      *scheduler.Scheduler
+		deps   *actions.Dependencies
 	}
 	var tests []struct {
 		name    string
@@ -20,7 +24,7 @@ func TestInitWebhooks(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := InitWebhooks(tt.args.logger, tt.args.cs, tt.args.c); (err != nil) != tt.wantErr {
+			if err := InitWebhooks(tt.args.logger, tt.args.cs, tt.args.c, tt.args.s, tt.args.deps); (err != nil) != tt.wantErr {
 				t.Errorf("InitWebhooks() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
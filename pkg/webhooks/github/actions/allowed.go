@@ -0,0 +1,312 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/breaker"
+	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// AllowedActionsAction keeps the allowed actions settings of organizations and repositories in line with the config
+type AllowedActionsAction struct {
+	logger  *zap.SugaredLogger
+	client  *clients.Github
+	audit   *audit.Recorder
+	breaker *breaker.Breaker
+
+	config            config.AllowedActionsConfig
+	globalEnforcement config.Enforcement
+
+	mu         sync.Mutex
+	reconciled map[string]time.Time
+}
+
+// allowedActionsDrift describes a setting that differs from the config. The allow-list only applies while the
+// target allows selected actions, a different permission is drift as well.
+type allowedActionsDrift struct {
+	Organization          string                 `json:"organization"`
+	Repository            string                 `json:"repository,omitempty"`
+	CurrentAllowedActions string                 `json:"current_allowed_actions"`
+	DesiredAllowedActions string                 `json:"desired_allowed_actions"`
+	Current               *github.ActionsAllowed `json:"current"`
+	Desired               *github.ActionsAllowed `json:"desired"`
+}
+
+func NewAllowedActionsAction(logger *zap.SugaredLogger, client *clients.Github, rawConfig map[string]any, deps *Dependencies) (*AllowedActionsAction, error) {
+	var c config.AllowedActionsConfig
+	if err := decodeArgs(rawConfig, &c); err != nil {
+		return nil, err
+	}
+	if c.Interval <= 0 {
+		c.Interval = utils.DefaultAllowedActionsInterval
+	}
+	if c.EventInterval <= 0 {
+		c.EventInterval = utils.DefaultAllowedActionsEventInterval
+	}
+	if err := validateEnforcement(c.Enforcement); err != nil {
+		return nil, err
+	}
+	if err := validateEnforcement(deps.Enforcement); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range c.Policies {
+		if p.Organization == "" {
+			return nil, fmt.Errorf(utils.ErrInvalidAllowedActionsPolicy, i, "organization is required")
+		}
+		target := allowedActionsTarget(p.Organization, p.Repository)
+		if seen[target] {
+			return nil, fmt.Errorf(utils.ErrInvalidAllowedActionsPolicy, i, "duplicate policy for "+target)
+		}
+		seen[target] = true
+	}
+
+	return &AllowedActionsAction{
+		logger:            logger,
+		client:            client,
+		audit:             deps.Audit,
+		breaker:           deps.Breaker,
+		config:            c,
+		globalEnforcement: deps.Enforcement,
+		reconciled:        make(map[string]time.Time),
+	}, nil
+}
+
+func allowedActionsTarget(org, repo string) string {
+	if repo == "" {
+		return org
+	}
+	return org + "/" + repo
+}
+
+func desiredActionsAllowed(p config.AllowedActionsPolicy) *github.ActionsAllowed {
+	patterns := append([]string{}, p.PatternsAllowed...)
+	sort.Strings(patterns)
+	return &github.ActionsAllowed{
+		GithubOwnedAllowed: github.Bool(p.GithubOwnedAllowed),
+		VerifiedAllowed:    github.Bool(p.VerifiedAllowed),
+		PatternsAllowed:    patterns,
+	}
+}
+
+// actionsAllowedEqual compares two settings, the order of the patterns does not matter
+func actionsAllowedEqual(a, b *github.ActionsAllowed) bool {
+	if a.GetGithubOwnedAllowed() != b.GetGithubOwnedAllowed() || a.GetVerifiedAllowed() != b.GetVerifiedAllowed() {
+		return false
+	}
+	if len(a.PatternsAllowed) != len(b.PatternsAllowed) {
+		return false
+	}
+
+	patterns := make(map[string]int)
+	for _, pattern := range a.PatternsAllowed {
+		patterns[pattern]++
+	}
+	for _, pattern := range b.PatternsAllowed {
+		if patterns[pattern] == 0 {
+			return false
+		}
+		patterns[pattern]--
+	}
+	return true
+}
+
+// Reconcile applies every configured policy
+func (a *AllowedActionsAction) Reconcile(ctx context.Context) error {
+	for _, p := range a.config.Policies {
+		if err := a.reconcilePolicy(ctx, p); err != nil {
+			a.logger.Errorw("error reconciling allowed actions", "target", allowedActionsTarget(p.Organization, p.Repository), "error", err)
+		}
+	}
+	return nil
+}
+
+// HandleEvent reconciles the policies of the organization and repository of a webhook event. Targets that
+// were reconciled less than event_interval ago are skipped to keep busy repositories from exhausting the rate limit.
+func (a *AllowedActionsAction) HandleEvent(ctx context.Context, org, repo string) {
+	for _, p := range a.config.Policies {
+		if p.Organization != org || (p.Repository != "" && p.Repository != repo) {
+			continue
+		}
+		if !a.due(allowedActionsTarget(p.Organization, p.Repository), time.Now()) {
+			continue
+		}
+		if err := a.reconcilePolicy(ctx, p); err != nil {
+			a.logger.Errorw("error reconciling allowed actions", "target", allowedActionsTarget(p.Organization, p.Repository), "error", err)
+		}
+	}
+}
+
+func (a *AllowedActionsAction) due(target string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if last, ok := a.reconciled[target]; ok && now.Sub(last) < a.config.EventInterval {
+		return false
+	}
+	a.reconciled[target] = now
+	return true
+}
+
+// reconcilePolicy switches the target to selected actions and applies the allow-list. In audit mode or while the
+// circuit breaker is open the drift is only recorded.
+func (a *AllowedActionsAction) reconcilePolicy(ctx context.Context, p config.AllowedActionsPolicy) error {
+	client, err := a.targetClient(p)
+	if err != nil {
+		return err
+	}
+
+	permissions, err := a.getPermissions(ctx, client, p)
+	if err != nil {
+		return err
+	}
+
+	// the allow-list cannot be read before selected actions are allowed
+	current := &github.ActionsAllowed{}
+	if permissions.AllowedActions == utils.ActionsPermissionSelected {
+		if p.Repository == "" {
+			current, _, err = client.GetV3Client().Organizations.GetActionsAllowed(ctx, p.Organization)
+		} else {
+			current, _, err = client.GetV3Client().Repositories.GetActionsAllowed(ctx, p.Organization, p.Repository)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	desired := desiredActionsAllowed(p)
+	if permissions.AllowedActions == utils.ActionsPermissionSelected && actionsAllowedEqual(current, desired) {
+		return nil
+	}
+
+	target := allowedActionsTarget(p.Organization, p.Repository)
+	drift := &allowedActionsDrift{
+		Organization:          p.Organization,
+		Repository:            p.Repository,
+		CurrentAllowedActions: permissions.AllowedActions,
+		DesiredAllowedActions: utils.ActionsPermissionSelected,
+		Current:               current,
+		Desired:               desired,
+	}
+
+	if mode := a.enforcementMode(p.Organization); mode == utils.EnforcementModeAudit {
+		a.logger.Infow("audit mode, allowed actions drift not reverted", "target", target, "allowed_actions", permissions.AllowedActions, "current", current, "desired", desired)
+		a.recordDrift(p, utils.AuditActionAllowedActionsWouldRevert, mode, drift)
+		return nil
+	}
+	if !a.breaker.Allow(ctx, p.Organization) {
+		a.logger.Warnw("circuit breaker open, allowed actions drift not reverted", "target", target)
+		a.recordDrift(p, utils.AuditActionAllowedActionsWouldRevert, utils.EnforcementModeAudit, drift)
+		return nil
+	}
+
+	if permissions.AllowedActions != utils.ActionsPermissionSelected {
+		if err := a.selectActions(ctx, client, p, permissions); err != nil {
+			return err
+		}
+	}
+
+	if p.Repository == "" {
+		_, _, err = client.GetV3Client().Organizations.EditActionsAllowed(ctx, p.Organization, *desired)
+	} else {
+		_, _, err = client.GetV3Client().Repositories.EditActionsAllowed(ctx, p.Organization, p.Repository, *desired)
+	}
+	if err != nil {
+		return err
+	}
+
+	a.logger.Warnw("allowed actions drift reverted", "target", target, "allowed_actions", permissions.AllowedActions, "current", current, "desired", desired)
+	a.recordDrift(p, utils.AuditActionAllowedActionsReverted, utils.EnforcementModeEnforce, drift)
+	return nil
+}
+
+// actionsPermissions is the part of the organization and repository permissions the policies depend on
+type actionsPermissions struct {
+	AllowedActions      string
+	EnabledRepositories string
+	Enabled             bool
+}
+
+func (a *AllowedActionsAction) getPermissions(ctx context.Context, client *clients.Github, p config.AllowedActionsPolicy) (*actionsPermissions, error) {
+	if p.Repository == "" {
+		permissions, _, err := client.GetV3Client().Organizations.GetActionsPermissions(ctx, p.Organization)
+		if err != nil {
+			return nil, err
+		}
+		return &actionsPermissions{AllowedActions: permissions.GetAllowedActions(), EnabledRepositories: permissions.GetEnabledRepositories()}, nil
+	}
+
+	permissions, _, err := client.GetV3Client().Repositories.GetActionsPermissions(ctx, p.Organization, p.Repository)
+	if err != nil {
+		return nil, err
+	}
+	return &actionsPermissions{AllowedActions: permissions.GetAllowedActions(), Enabled: permissions.GetEnabled()}, nil
+}
+
+// selectActions restricts the target to selected actions, which repositories run Actions is left untouched
+func (a *AllowedActionsAction) selectActions(ctx context.Context, client *clients.Github, p config.AllowedActionsPolicy, current *actionsPermissions) error {
+	if p.Repository == "" {
+		_, _, err := client.GetV3Client().Organizations.EditActionsPermissions(ctx, p.Organization, github.ActionsPermissions{
+			EnabledRepositories: github.String(current.EnabledRepositories),
+			AllowedActions:      github.String(utils.ActionsPermissionSelected),
+		})
+		return err
+	}
+	_, _, err := client.GetV3Client().Repositories.EditActionsPermissions(ctx, p.Organization, p.Repository, github.ActionsPermissionsRepository{
+		Enabled:        github.Bool(current.Enabled),
+		AllowedActions: github.String(utils.ActionsPermissionSelected),
+	})
+	return err
+}
+
+// enforcementMode falls back to audit while the circuit breaker is open for the organization
+func (a *AllowedActionsAction) enforcementMode(org string) string {
+	if a.breaker.Tripped(org) {
+		return utils.EnforcementModeAudit
+	}
+	return resolveEnforcementMode(a.config.Enforcement, a.globalEnforcement, org)
+}
+
+func (a *AllowedActionsAction) recordDrift(p config.AllowedActionsPolicy, action, mode string, drift *allowedActionsDrift) {
+	if err := a.audit.Record(audit.Entry{
+		Organization: p.Organization,
+		Repository:   p.Repository,
+		Action:       action,
+		Mode:         mode,
+		Result:       drift,
+	}); err != nil {
+		a.logger.Errorw(utils.LoggerErrorRecordingAudit, "error", err)
+	}
+}
+
+// targetClient returns the client of the installation covering the policy, organization policies have no
+// repository to look the installation up with
+func (a *AllowedActionsAction) targetClient(p config.AllowedActionsPolicy) (*clients.Github, error) {
+	if p.Repository == "" {
+		return a.client.ForOrganization(p.Organization)
+	}
+	return a.client.ForRepository(p.Organization, p.Repository)
+}
+
+func (a *AllowedActionsAction) jobs() []scheduler.Job {
+	if len(a.config.Policies) == 0 {
+		return nil
+	}
+	return []scheduler.Job{{
+		Name:     fmt.Sprintf("allowed-actions-%s", a.client.Organization()),
+		Interval: a.config.Interval,
+		Run:      a.Reconcile,
+	}}
+}
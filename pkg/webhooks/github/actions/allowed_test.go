@@ -0,0 +1,194 @@
+package actions
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestActionsAllowedEqual(t *testing.T) {
+	desired := desiredActionsAllowed(config.AllowedActionsPolicy{
+		GithubOwnedAllowed: true,
+		PatternsAllowed:    []string{"orgs-tools/*", "docker/*"},
+	})
+
+	tests := []struct {
+		name    string
+		current *github.ActionsAllowed
+		want    bool
+	}{
+		{
+			name:    "same patterns in different order",
+			current: &github.ActionsAllowed{GithubOwnedAllowed: github.Bool(true), VerifiedAllowed: github.Bool(false), PatternsAllowed: []string{"docker/*", "orgs-tools/*"}},
+			want:    true,
+		},
+		{
+			name:    "verified creators enabled in the UI",
+			current: &github.ActionsAllowed{GithubOwnedAllowed: github.Bool(true), VerifiedAllowed: github.Bool(true), PatternsAllowed: []string{"docker/*", "orgs-tools/*"}},
+			want:    false,
+		},
+		{
+			name:    "additional pattern",
+			current: &github.ActionsAllowed{GithubOwnedAllowed: github.Bool(true), PatternsAllowed: []string{"docker/*", "orgs-tools/*", "*"}},
+			want:    false,
+		},
+		{
+			name:    "replaced pattern",
+			current: &github.ActionsAllowed{GithubOwnedAllowed: github.Bool(true), PatternsAllowed: []string{"docker/*", "*"}},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := actionsAllowedEqual(tt.current, desired); got != tt.want {
+				t.Errorf("actionsAllowedEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedActionsAction_due(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	a := &AllowedActionsAction{
+		config:     config.AllowedActionsConfig{EventInterval: 5 * time.Minute},
+		reconciled: make(map[string]time.Time),
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		now    time.Time
+		want   bool
+	}{
+		{name: "first event", target: "orgs-tools", now: now, want: true},
+		{name: "within event interval", target: "orgs-tools", now: now.Add(time.Minute), want: false},
+		{name: "other target", target: "orgs-tools/api", now: now.Add(time.Minute), want: true},
+		{name: "after event interval", target: "orgs-tools", now: now.Add(6 * time.Minute), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.due(tt.target, tt.now); got != tt.want {
+				t.Errorf("due() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedActionsAction_reconcilePolicy(t *testing.T) {
+	const (
+		orgPermissions  = "/orgs/mo-octocat/actions/permissions"
+		orgSelected     = "/orgs/mo-octocat/actions/permissions/selected-actions"
+		repoPermissions = "/repos/mo-octocat/flutter-template/actions/permissions"
+		repoSelected    = "/repos/mo-octocat/flutter-template/actions/permissions/selected-actions"
+	)
+	orgPolicy := config.AllowedActionsPolicy{Organization: testOrganization, GithubOwnedAllowed: true}
+
+	tests := []struct {
+		name        string
+		policy      config.AllowedActionsPolicy
+		rawConfig   map[string]any
+		permissions string
+		current     map[string]any
+		selected    string
+		edits       []string
+		wantAction  string
+	}{
+		{
+			name:        "organization",
+			policy:      orgPolicy,
+			permissions: orgPermissions,
+			current:     map[string]any{"enabled_repositories": "all", "allowed_actions": "selected"},
+			selected:    orgSelected,
+			edits:       []string{orgSelected},
+			wantAction:  utils.AuditActionAllowedActionsReverted,
+		},
+		{
+			name:        "repository",
+			policy:      config.AllowedActionsPolicy{Organization: testOrganization, Repository: "flutter-template", GithubOwnedAllowed: true},
+			permissions: repoPermissions,
+			current:     map[string]any{"enabled": true, "allowed_actions": "selected"},
+			selected:    repoSelected,
+			edits:       []string{repoSelected},
+			wantAction:  utils.AuditActionAllowedActionsReverted,
+		},
+		{
+			name:        "organization allowing all actions",
+			policy:      orgPolicy,
+			permissions: orgPermissions,
+			current:     map[string]any{"enabled_repositories": "selected", "allowed_actions": "all"},
+			selected:    orgSelected,
+			edits:       []string{orgPermissions, orgSelected},
+			wantAction:  utils.AuditActionAllowedActionsReverted,
+		},
+		{
+			name:        "audit mode",
+			policy:      orgPolicy,
+			rawConfig:   map[string]any{"enforcement": map[string]any{"mode": utils.EnforcementModeAudit}},
+			permissions: orgPermissions,
+			current:     map[string]any{"enabled_repositories": "all", "allowed_actions": "all"},
+			selected:    orgSelected,
+			wantAction:  utils.AuditActionAllowedActionsWouldRevert,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := zap.NewNop().Sugar()
+			fake := newFakeGitHub(t)
+			fake.reply(http.MethodGet, tt.permissions, http.StatusOK, tt.current)
+			fake.reply(http.MethodPut, tt.permissions, http.StatusNoContent, nil)
+			if tt.current["allowed_actions"] == "selected" {
+				fake.reply(http.MethodGet, tt.selected, http.StatusOK, map[string]any{"github_owned_allowed": false})
+			} else {
+				fake.reply(http.MethodGet, tt.selected, http.StatusConflict, map[string]any{"message": "allowed_actions is not selected"})
+			}
+			fake.reply(http.MethodPut, tt.selected, http.StatusNoContent, nil)
+
+			recorder := audit.New(logger, &config.Audit{Path: filepath.Join(t.TempDir(), "audit.log")})
+			rawConfig := tt.rawConfig
+			if rawConfig == nil {
+				rawConfig = map[string]any{}
+			}
+			a, err := NewAllowedActionsAction(logger, fake.client(t, testOrganization, testRepository), rawConfig, &Dependencies{Audit: recorder})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := a.reconcilePolicy(context.Background(), tt.policy); err != nil {
+				t.Fatalf("reconcilePolicy() error = %v", err)
+			}
+
+			var edits []string
+			for _, r := range fake.requests {
+				if r.Method == http.MethodPut {
+					edits = append(edits, r.Path)
+				}
+			}
+			if !reflect.DeepEqual(edits, tt.edits) {
+				t.Errorf("reconcilePolicy() edited %v, want %v", edits, tt.edits)
+			}
+			if tt.permissions == orgPermissions && len(tt.edits) > 1 {
+				body := fake.called(http.MethodPut, orgPermissions)[0].Body
+				if body["allowed_actions"] != "selected" || body["enabled_repositories"] != tt.current["enabled_repositories"] {
+					t.Errorf("reconcilePolicy() set permissions %v, want selected actions for %v repositories", body, tt.current["enabled_repositories"])
+				}
+			}
+
+			entries, err := recorder.Entries(time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Action != tt.wantAction {
+				t.Fatalf("reconcilePolicy() recorded %v, want one %s entry", entries, tt.wantAction)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package actions
+
+import (
+	"context"
+
+	ghwebhooks "github.com/go-playground/webhooks/v6/github"
+	"github.com/google/go-github/v50/github"
+
+	"github.tools.sap/actions-rollout-app/pkg/analysis"
+)
+
+// analyzeWorkflow adds the findings of the triggering workflow file at the head commit to the result.
+// The workflow is only known for workflow runs, a workflow that cannot be read is logged and skipped.
+func (w *WorkflowAction) analyzeWorkflow(ctx context.Context, p *WorkflowActionParams, result *ValidationResult) {
+	if w.analyzer == nil || result == nil || p.WebhookEvent != ghwebhooks.WorkflowRunEvent || p.WorkflowPath == "" || p.HeadSHA == "" {
+		return
+	}
+
+	content, err := w.readWorkflow(ctx, p)
+	if err != nil {
+		w.logger.Errorw("error reading workflow", "organization", p.Organization, "repository", p.Repository, "path", p.WorkflowPath, "ref", p.HeadSHA, "error", err)
+		return
+	}
+
+	findings, err := w.analyzer.Analyze(p.WorkflowPath, content, p.Organization)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+	result.Findings = append(result.Findings, findings...)
+}
+
+func (w *WorkflowAction) readWorkflow(ctx context.Context, p *WorkflowActionParams) ([]byte, error) {
+	repoClient, err := w.client.ForRepository(p.Organization, p.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	file, _, _, err := repoClient.GetV3Client().Repositories.GetContents(ctx, p.Organization, p.Repository, p.WorkflowPath, &github.RepositoryContentGetOptions{Ref: p.HeadSHA})
+	if err != nil {
+		return nil, err
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+func newAnalyzer(enabled bool, rules, trustedOwners []string) (*analysis.Analyzer, error) {
+	if !enabled {
+		return nil, nil
+	}
+	return analysis.New(rules, trustedOwners)
+}
@@ -0,0 +1,299 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// defaultAssigneeFallback is tried in order when routing is enabled without a fallback
+var defaultAssigneeFallback = []string{
+	utils.AssigneeSourceRegistration,
+	utils.AssigneeSourceCodeowners,
+	utils.AssigneeSourceOrgAdmins,
+	utils.AssigneeSourceDefault,
+}
+
+// assigneeDirectory resolves the entries of routing rules and registrations to logins
+type assigneeDirectory interface {
+	EmailLogins(ctx context.Context, org string) (map[string]string, error)
+	TeamMembers(ctx context.Context, org, slug string) ([]string, error)
+	OrgAdmins(ctx context.Context, org string) ([]string, error)
+}
+
+func (d *githubDirectory) TeamMembers(ctx context.Context, org, slug string) ([]string, error) {
+	var logins []string
+	opts := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		members, resp, err := d.client.GetV3Client().Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			logins = append(logins, member.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return logins, nil
+}
+
+func (d *githubDirectory) OrgAdmins(ctx context.Context, org string) ([]string, error) {
+	var logins []string
+	opts := &github.ListMembersOptions{Role: "admin", ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		members, resp, err := d.client.GetV3Client().Organizations.ListMembers(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			logins = append(logins, member.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return logins, nil
+}
+
+// isAssignee checks whether the user can be assigned to issues of the repository
+func isAssignee(ctx context.Context, r *issueRepository, login string) (bool, error) {
+	ok, _, err := r.client.GetV3Client().Issues.IsAssignee(ctx, r.owner, r.name, login)
+	return ok, err
+}
+
+type cachedAssignee struct {
+	ok      bool
+	fetched time.Time
+}
+
+// assigneeRouter picks the assignees of workflow issues from the routing rules and the fallback chain and skips
+// users that cannot be assigned in the target repository
+type assigneeRouter struct {
+	logger    *zap.SugaredLogger
+	enabled   bool
+	rules     []config.AssigneeRule
+	fallback  []string
+	defaults  []string
+	directory assigneeDirectory
+	check     func(ctx context.Context, r *issueRepository, login string) (bool, error)
+	now       func() time.Time
+
+	mu     sync.Mutex
+	cache  map[string]cachedAssignee
+	emails map[string]cachedEmails
+}
+
+func newAssigneeRouter(logger *zap.SugaredLogger, c config.AssigneeRoutingConfig, defaults []string, directory assigneeDirectory) (*assigneeRouter, error) {
+	for i, rule := range c.Rules {
+		if len(rule.Repositories) == 0 || len(rule.Assignees) == 0 {
+			return nil, fmt.Errorf(utils.ErrInvalidAssigneeRule, i, "repositories and assignees are required")
+		}
+		for _, pattern := range rule.Repositories {
+			if _, err := path.Match(pattern, ""); err != nil || strings.Count(pattern, "/") != 1 {
+				return nil, fmt.Errorf(utils.ErrInvalidAssigneeRule, i, fmt.Sprintf("invalid pattern %q, expected org/repo", pattern))
+			}
+		}
+	}
+
+	fallback := c.Fallback
+	if len(fallback) == 0 {
+		fallback = defaultAssigneeFallback
+	}
+	for _, source := range fallback {
+		switch source {
+		case utils.AssigneeSourceRegistration, utils.AssigneeSourceCodeowners, utils.AssigneeSourceOrgAdmins, utils.AssigneeSourceDefault:
+		default:
+			return nil, fmt.Errorf(utils.ErrUnknownAssigneeSource, source)
+		}
+	}
+
+	return &assigneeRouter{
+		logger:    logger,
+		enabled:   c.Enabled,
+		rules:     c.Rules,
+		fallback:  fallback,
+		defaults:  defaults,
+		directory: directory,
+		check:     isAssignee,
+		now:       time.Now,
+		cache:     make(map[string]cachedAssignee),
+		emails:    make(map[string]cachedEmails),
+	}, nil
+}
+
+// rule returns the assignees of the first rule matching the repository
+func (a *assigneeRouter) rule(org, repo string) []string {
+	fullName := strings.ToLower(org + "/" + repo)
+	for _, rule := range a.rules {
+		for _, pattern := range rule.Repositories {
+			if ok, _ := path.Match(strings.ToLower(pattern), fullName); ok {
+				return rule.Assignees
+			}
+		}
+	}
+	return nil
+}
+
+// chain returns the sources tried for an issue. Without routing, central issues keep issue_assignees and issues
+// in the offending repository are assigned to its CODEOWNERS.
+func (a *assigneeRouter) chain(central bool) []string {
+	switch {
+	case a.enabled:
+		return append([]string{utils.AssigneeSourceRule}, a.fallback...)
+	case central:
+		return []string{utils.AssigneeSourceDefault}
+	default:
+		return []string{utils.AssigneeSourceCodeowners}
+	}
+}
+
+// expand resolves users, email addresses of members of org and org/team-slug teams to logins, duplicates are dropped
+func (a *assigneeRouter) expand(ctx context.Context, org string, entries []string) []string {
+	seen := make(map[string]bool)
+	var logins []string
+	add := func(login string) {
+		if login != "" && !seen[strings.ToLower(login)] {
+			seen[strings.ToLower(login)] = true
+			logins = append(logins, login)
+		}
+	}
+
+	for _, entry := range entries {
+		entry = strings.TrimPrefix(strings.TrimSpace(entry), "@")
+		switch {
+		case entry == "":
+		case strings.Contains(entry, "/"):
+			org, slug, _ := strings.Cut(entry, "/")
+			members, err := a.directory.TeamMembers(ctx, org, slug)
+			if err != nil {
+				a.logger.Warnw("error listing team members", "team", entry, "error", err)
+				continue
+			}
+			for _, member := range members {
+				add(member)
+			}
+		case strings.Contains(entry, "@"):
+			emails, err := a.emailLogins(ctx, org)
+			if err != nil {
+				a.logger.Warnw("error looking up email", "email", entry, "error", err)
+				continue
+			}
+			login := emails[strings.ToLower(entry)]
+			if login == "" {
+				a.logger.Infow("email is not mapped to a member, skipped", "email", entry, "organization", org)
+				continue
+			}
+			add(login)
+		default:
+			add(entry)
+		}
+	}
+	return logins
+}
+
+func (a *assigneeRouter) emailLogins(ctx context.Context, org string) (map[string]string, error) {
+	a.mu.Lock()
+	cached, ok := a.emails[org]
+	a.mu.Unlock()
+	if ok && a.now().Sub(cached.fetched) < utils.DefaultAssigneeCacheTTL {
+		return cached.logins, nil
+	}
+
+	logins, err := a.directory.EmailLogins(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.emails[org] = cachedEmails{logins: logins, fetched: a.now()}
+	a.mu.Unlock()
+
+	return logins, nil
+}
+
+// candidates returns the entries of a source, codeowners is only read when the source is reached
+func (a *assigneeRouter) candidates(ctx context.Context, source string, p *WorkflowActionParams, result *ValidationResult, codeowners func() []string) []string {
+	switch source {
+	case utils.AssigneeSourceRule:
+		return a.rule(p.Organization, p.Repository)
+	case utils.AssigneeSourceRegistration:
+		registration := result.Registration()
+		if registration == nil {
+			return nil
+		}
+		return []string{registration.ContactEmail, registration.Owner}
+	case utils.AssigneeSourceCodeowners:
+		return codeowners()
+	case utils.AssigneeSourceOrgAdmins:
+		admins, err := a.directory.OrgAdmins(ctx, p.Organization)
+		if err != nil {
+			a.logger.Warnw("error listing organization admins", "organization", p.Organization, "error", err)
+		}
+		return admins
+	case utils.AssigneeSourceDefault:
+		return a.defaults
+	}
+	return nil
+}
+
+// route returns the assignable users of the first source of the chain that yields any, together with the source
+func (a *assigneeRouter) route(ctx context.Context, r *issueRepository, central bool, p *WorkflowActionParams, result *ValidationResult, codeowners func() []string) ([]string, string) {
+	for _, source := range a.chain(central) {
+		assignees := a.assignable(ctx, r, a.expand(ctx, p.Organization, a.candidates(ctx, source, p, result, codeowners)))
+		if len(assignees) > 0 {
+			return assignees, source
+		}
+	}
+	return nil, ""
+}
+
+// assignable drops the users that cannot be assigned to issues of the repository, at most MaxIssueAssignees are kept
+func (a *assigneeRouter) assignable(ctx context.Context, r *issueRepository, logins []string) []string {
+	var assignees []string
+	for _, login := range logins {
+		if len(assignees) == utils.MaxIssueAssignees {
+			a.logger.Infow("too many assignees, remaining ones skipped", "repository", r.String(), "limit", utils.MaxIssueAssignees)
+			break
+		}
+
+		key := strings.ToLower(r.String() + ":" + login)
+		a.mu.Lock()
+		cached, found := a.cache[key]
+		a.mu.Unlock()
+		if !found || a.now().Sub(cached.fetched) >= utils.DefaultAssigneeCacheTTL {
+			ok, err := a.check(ctx, r, login)
+			if err != nil {
+				a.logger.Warnw("error checking assignee, skipped", "repository", r.String(), "assignee", login, "error", err)
+				continue
+			}
+			cached = cachedAssignee{ok: ok, fetched: a.now()}
+			a.mu.Lock()
+			a.cache[key] = cached
+			a.mu.Unlock()
+		}
+
+		if !cached.ok {
+			a.logger.Infow("assignee cannot be assigned, skipped", "repository", r.String(), "assignee", login)
+			continue
+		}
+		assignees = append(assignees, login)
+	}
+	return assignees
+}
@@ -0,0 +1,162 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+type fakeAssigneeDirectory struct {
+	emails map[string]string
+	teams  map[string][]string
+	admins map[string][]string
+}
+
+func (f *fakeAssigneeDirectory) EmailLogins(_ context.Context, _ string) (map[string]string, error) {
+	return f.emails, nil
+}
+
+func (f *fakeAssigneeDirectory) TeamMembers(_ context.Context, org, slug string) ([]string, error) {
+	members, ok := f.teams[org+"/"+slug]
+	if !ok {
+		return nil, errors.New("team not found")
+	}
+	return members, nil
+}
+
+func (f *fakeAssigneeDirectory) OrgAdmins(_ context.Context, org string) ([]string, error) {
+	return f.admins[org], nil
+}
+
+func newTestAssigneeRouter(t *testing.T, c config.AssigneeRoutingConfig, assignable map[string]bool) (*assigneeRouter, *int) {
+	directory := &fakeAssigneeDirectory{
+		emails: map[string]string{"team-a@example.com": "alice"},
+		teams:  map[string][]string{"mo-octocat/platform": {"bob", "carol"}},
+		admins: map[string][]string{"mo-octocat": {"admin"}},
+	}
+	a, err := newAssigneeRouter(zap.NewNop().Sugar(), c, []string{"mouismail"}, directory)
+	if err != nil {
+		t.Fatalf("newAssigneeRouter() error = %v", err)
+	}
+	checks := 0
+	a.check = func(_ context.Context, _ *issueRepository, login string) (bool, error) {
+		checks++
+		return assignable[login], nil
+	}
+	return a, &checks
+}
+
+func TestNewAssigneeRouter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.AssigneeRoutingConfig
+		wantErr bool
+	}{
+		{name: "defaults", config: config.AssigneeRoutingConfig{Enabled: true}},
+		{name: "rule", config: config.AssigneeRoutingConfig{Rules: []config.AssigneeRule{{Repositories: []string{"mo-octocat/flutter-*"}, Assignees: []string{"mo-octocat/platform"}}}}},
+		{name: "rule without assignees", config: config.AssigneeRoutingConfig{Rules: []config.AssigneeRule{{Repositories: []string{"mo-octocat/*"}}}}, wantErr: true},
+		{name: "pattern without org", config: config.AssigneeRoutingConfig{Rules: []config.AssigneeRule{{Repositories: []string{"flutter-*"}, Assignees: []string{"bob"}}}}, wantErr: true},
+		{name: "malformed pattern", config: config.AssigneeRoutingConfig{Rules: []config.AssigneeRule{{Repositories: []string{"mo-octocat/[a"}, Assignees: []string{"bob"}}}}, wantErr: true},
+		{name: "unknown source", config: config.AssigneeRoutingConfig{Fallback: []string{"registration", "oncall"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAssigneeRouter(zap.NewNop().Sugar(), tt.config, nil, &fakeAssigneeDirectory{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newAssigneeRouter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssigneeRouter_route(t *testing.T) {
+	routing := config.AssigneeRoutingConfig{
+		Enabled: true,
+		Rules: []config.AssigneeRule{
+			{Repositories: []string{"mo-octocat/flutter-*"}, Assignees: []string{"@mo-octocat/platform", "ghost"}},
+			{Repositories: []string{"*/legacy"}, Assignees: []string{"ghost"}},
+		},
+	}
+	registered := &ValidationResult{Files: []FileResult{{Applies: true, Registration: &ValidatorData{ContactEmail: "team-a@example.com", Owner: "ghost"}}}}
+	assignable := map[string]bool{"alice": true, "bob": true, "carol": true, "admin": true, "mouismail": true, "dave": true}
+
+	tests := []struct {
+		name       string
+		routing    config.AssigneeRoutingConfig
+		central    bool
+		repo       string
+		result     *ValidationResult
+		codeowners []string
+		want       []string
+		wantSource string
+	}{
+		{name: "rule with team", routing: routing, central: true, repo: "flutter-template", want: []string{"bob", "carol"}, wantSource: utils.AssigneeSourceRule},
+		{name: "rule without assignable users falls back", routing: routing, central: true, repo: "legacy", result: registered, want: []string{"alice"}, wantSource: utils.AssigneeSourceRegistration},
+		{name: "codeowners", routing: routing, central: true, repo: "app", codeowners: []string{"@dave", "@mo-octocat/platform"}, want: []string{"dave", "bob", "carol"}, wantSource: utils.AssigneeSourceCodeowners},
+		{name: "org admins", routing: routing, central: true, repo: "app", want: []string{"admin"}, wantSource: utils.AssigneeSourceOrgAdmins},
+		{
+			name:       "custom fallback",
+			routing:    config.AssigneeRoutingConfig{Enabled: true, Fallback: []string{"default"}},
+			central:    true,
+			repo:       "app",
+			result:     registered,
+			want:       []string{"mouismail"},
+			wantSource: utils.AssigneeSourceDefault,
+		},
+		{name: "routing disabled central", central: true, repo: "flutter-template", result: registered, want: []string{"mouismail"}, wantSource: utils.AssigneeSourceDefault},
+		{name: "routing disabled repository", repo: "flutter-template", codeowners: []string{"@dave"}, want: []string{"dave"}, wantSource: utils.AssigneeSourceCodeowners},
+		{name: "nobody assignable", repo: "flutter-template", codeowners: []string{"@ghost"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newTestAssigneeRouter(t, tt.routing, assignable)
+			p := &WorkflowActionParams{Organization: "mo-octocat", Repository: tt.repo}
+			codeowners := func() []string { return tt.codeowners }
+
+			got, source := a.route(context.Background(), &issueRepository{owner: "mo-octocat", name: "actions-control"}, tt.central, p, tt.result, codeowners)
+			if !reflect.DeepEqual(got, tt.want) || source != tt.wantSource {
+				t.Errorf("route() = %v, %q, want %v, %q", got, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestAssigneeRouter_assignable(t *testing.T) {
+	a, checks := newTestAssigneeRouter(t, config.AssigneeRoutingConfig{}, map[string]bool{"alice": true})
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	r := &issueRepository{owner: "mo-octocat", name: "actions-control"}
+
+	if got := a.assignable(context.Background(), r, []string{"alice", "ghost"}); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("assignable() = %v, want [alice]", got)
+	}
+	a.assignable(context.Background(), r, []string{"Alice", "ghost"})
+	if *checks != 2 {
+		t.Errorf("checks = %d, want 2 with cached results", *checks)
+	}
+
+	now = now.Add(utils.DefaultAssigneeCacheTTL)
+	a.assignable(context.Background(), r, []string{"alice"})
+	if *checks != 3 {
+		t.Errorf("checks = %d, want 3 after the cache expired", *checks)
+	}
+
+	many := make([]string, 0, 12)
+	assignable := make(map[string]bool)
+	for i := 0; i < 12; i++ {
+		login := string(rune('a'+i)) + "-user"
+		many = append(many, login)
+		assignable[login] = true
+	}
+	a, _ = newTestAssigneeRouter(t, config.AssigneeRoutingConfig{}, assignable)
+	if got := len(a.assignable(context.Background(), r, many)); got != utils.MaxIssueAssignees {
+		t.Errorf("assignable() kept %d assignees, want %d", got, utils.MaxIssueAssignees)
+	}
+}
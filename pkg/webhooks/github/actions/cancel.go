@@ -0,0 +1,92 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// cancelable reports whether the event belongs to a run that is still queued or executing
+func cancelable(p *WorkflowActionParams) bool {
+	if p.RunID == 0 {
+		return false
+	}
+	return p.Action == utils.WorkflowRunActionRequested || p.Action == utils.WorkflowRunActionInProgress
+}
+
+// cancelRun cancels the run that triggered the event. Runs that are still not completed after
+// force_after are force cancelled in the background.
+func (w *WorkflowAction) cancelRun(ctx context.Context, p *WorkflowActionParams, result *ValidationResult) {
+	if !w.cancel.Enabled || !cancelable(p) {
+		return
+	}
+
+	repoClient, err := w.client.ForRepository(p.Organization, p.Repository)
+	if err != nil {
+		w.logger.Errorw("error cancelling workflow run", "run_id", p.RunID, "error", err)
+		return
+	}
+
+	resp, err := repoClient.GetV3Client().Actions.CancelWorkflowRunByID(ctx, p.Organization, p.Repository, p.RunID)
+	if resp != nil && resp.StatusCode == http.StatusConflict {
+		w.logger.Debugw("workflow run already completed", "run_id", p.RunID)
+		return
+	}
+	if err != nil {
+		w.logger.Errorw("error cancelling workflow run", "run_id", p.RunID, "error", err)
+		return
+	}
+	w.logger.Infow("workflow run cancelled", "run_id", p.RunID)
+	w.recordAudit(p, utils.AuditActionCancelled, result)
+
+	if w.cancel.ForceAfter > 0 {
+		go w.forceCancelStuckRun(repoClient, p, result)
+	}
+}
+
+func (w *WorkflowAction) forceCancelStuckRun(repoClient *clients.Github, p *WorkflowActionParams, result *ValidationResult) {
+	time.Sleep(w.cancel.ForceAfter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), utils.WebhookHandleTimeout)
+	defer cancel()
+
+	run, _, err := repoClient.GetV3Client().Actions.GetWorkflowRunByID(ctx, p.Organization, p.Repository, p.RunID)
+	if err != nil {
+		w.logger.Errorw("error reading workflow run", "run_id", p.RunID, "error", err)
+		return
+	}
+	if run.GetStatus() == utils.WorkflowRunStatusCompleted {
+		return
+	}
+
+	if err := forceCancelWorkflowRun(ctx, repoClient, p.Organization, p.Repository, p.RunID); err != nil {
+		w.logger.Errorw("error force cancelling workflow run", "run_id", p.RunID, "error", err)
+		return
+	}
+	w.logger.Infow("workflow run force cancelled", "run_id", p.RunID, "status", run.GetStatus())
+	w.recordAudit(p, utils.AuditActionForceCancelled, result)
+}
+
+// forceCancelWorkflowRun calls the force-cancel endpoint, which go-github does not wrap yet
+func forceCancelWorkflowRun(ctx context.Context, repoClient *clients.Github, owner, repo string, runID int64) error {
+	client := repoClient.GetV3Client()
+	req, err := client.NewRequest(http.MethodPost, fmt.Sprintf("repos/%s/%s/actions/runs/%d/force-cancel", owner, repo, runID), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return errors.New(strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
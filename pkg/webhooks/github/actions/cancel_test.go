@@ -0,0 +1,86 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestCancelable(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *WorkflowActionParams
+		want bool
+	}{
+		{name: "requested", p: &WorkflowActionParams{RunID: 1, Action: "requested"}, want: true},
+		{name: "in progress", p: &WorkflowActionParams{RunID: 1, Action: "in_progress"}, want: true},
+		{name: "completed", p: &WorkflowActionParams{RunID: 1, Action: "completed"}, want: false},
+		{name: "missing run id", p: &WorkflowActionParams{Action: "requested"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cancelable(tt.p); got != tt.want {
+				t.Errorf("cancelable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_handleWorkflowEvent_cancelsEnforcedRuns(t *testing.T) {
+	tests := []struct {
+		name      string
+		rawConfig map[string]any
+		runs      []int64
+		// wantCancelled are the runs that are cancelled, the breaker allows a single enforcement
+		wantCancelled map[int64]bool
+	}{
+		{
+			name:          "without escalation",
+			rawConfig:     map[string]any{},
+			runs:          []int64{1, 2},
+			wantCancelled: map[int64]bool{1: true},
+		},
+		{
+			name: "with escalation",
+			rawConfig: escalationConfig(
+				map[string]any{"action": utils.EscalationNotify},
+				map[string]any{"action": utils.EscalationDisable, "after_runs": 2},
+			),
+			runs:          []int64{1, 2, 3},
+			wantCancelled: map[int64]bool{2: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rawConfig["cancel_run"] = map[string]any{"enabled": true}
+			w := newTestWorkflowAction(t, tt.rawConfig, &config.CircuitBreaker{OrganizationThreshold: 1})
+			w.fake.reply(http.MethodPut, "/repos/mo-octocat/flutter-template/actions/workflows/42/disable", http.StatusNoContent, nil)
+			for _, runID := range tt.runs {
+				w.fake.reply(http.MethodPost, fmt.Sprintf("/repos/mo-octocat/flutter-template/actions/runs/%d/cancel", runID), http.StatusAccepted, nil)
+			}
+
+			for _, runID := range tt.runs {
+				for _, p := range deliveries(42, runID) {
+					_ = w.handleWorkflowEvent(context.Background(), p, "run")
+				}
+			}
+
+			for _, runID := range tt.runs {
+				cancelled := len(w.fake.called(http.MethodPost, fmt.Sprintf("/repos/mo-octocat/flutter-template/actions/runs/%d/cancel", runID))) > 0
+				if cancelled != tt.wantCancelled[runID] {
+					t.Errorf("run %d cancelled = %v, want %v", runID, cancelled, tt.wantCancelled[runID])
+				}
+			}
+			if got := len(w.fake.called(http.MethodPut, "/repos/mo-octocat/flutter-template/actions/workflows/42/disable")); got != 1 {
+				t.Errorf("workflow disabled %d times, want 1", got)
+			}
+			if !w.breaker.Tripped(testOrganization) {
+				t.Error("circuit breaker not tripped")
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v50/github"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// checkRun is the persisted record of the check run that reports a blocked workflow
+type checkRun struct {
+	Organization string    `json:"organization"`
+	Repository   string    `json:"repository"`
+	WorkflowID   int64     `json:"workflow_id"`
+	WorkflowName string    `json:"workflow_name"`
+	HeadSHA      string    `json:"head_sha"`
+	ID           int64     `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newCheckRuns(c config.CheckRunsConfig) (config.CheckRunsConfig, error) {
+	if c.Name == "" {
+		c.Name = utils.DefaultCheckRunName
+	}
+	if c.Conclusion == "" {
+		c.Conclusion = utils.CheckRunConclusionFailure
+	}
+	if c.Conclusion != utils.CheckRunConclusionFailure && c.Conclusion != utils.CheckRunConclusionActionRequired {
+		return c, fmt.Errorf(utils.ErrInvalidCheckRunConclusion, c.Conclusion)
+	}
+	return c, nil
+}
+
+// blockedOutput explains why the workflow is blocked and links to the registration instructions, without a
+// validation result the repository is reported as not registered
+func blockedOutput(p *WorkflowActionParams, result *ValidationResult, registrationURL string) *github.CheckRunOutput {
+	reason, details := "the repository is not registered", ""
+	if result != nil {
+		if err := result.Err(); err != nil {
+			reason = err.Error()
+		}
+		details = result.Markdown()
+	}
+	summary := fmt.Sprintf(utils.CheckRunBlockedSummary, p.WorkflowName, p.Organization, p.Repository, reason, registrationURL) + details
+	if len(summary) > utils.MaxCheckRunSummaryLength {
+		// cut on a rune boundary, the API rejects invalid UTF-8
+		cut := utils.MaxCheckRunSummaryLength
+		for cut > 0 && !utf8.RuneStart(summary[cut]) {
+			cut--
+		}
+		summary = summary[:cut]
+	}
+
+	return &github.CheckRunOutput{
+		Title:   github.String(fmt.Sprintf(utils.CheckRunBlockedTitle, p.WorkflowName)),
+		Summary: github.String(summary),
+	}
+}
+
+func resolvedOutput(record *checkRun) *github.CheckRunOutput {
+	return &github.CheckRunOutput{
+		Title:   github.String(fmt.Sprintf(utils.CheckRunResolvedTitle, record.Organization, record.Repository)),
+		Summary: github.String(fmt.Sprintf(utils.CheckRunResolvedSummary, record.Organization, record.Repository, record.WorkflowName)),
+	}
+}
+
+func supersededOutput(record *checkRun, headSHA string) *github.CheckRunOutput {
+	return &github.CheckRunOutput{
+		Title:   github.String(fmt.Sprintf(utils.CheckRunSupersededTitle, headSHA)),
+		Summary: github.String(fmt.Sprintf(utils.CheckRunSupersededSummary, record.WorkflowName, headSHA)),
+	}
+}
+
+// registrationURL links to the registration instructions, by default the central repository
+func (w *WorkflowAction) registrationURL() string {
+	if w.checkRuns.RegistrationURL != "" {
+		return w.checkRuns.RegistrationURL
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(w.client.ServerInfo().EnterpriseURL, "/"), w.organization, w.repository)
+}
+
+// reportCheckRun reports the blocked workflow as a check run on the head commit of the run, so that developers
+// see it next to their commit. A check run that already exists for the commit is updated instead, the check run of
+// a previous commit is completed as neutral.
+func (w *WorkflowAction) reportCheckRun(ctx context.Context, p *WorkflowActionParams, result *ValidationResult) {
+	if !w.checkRuns.Enabled || p.HeadSHA == "" {
+		return
+	}
+
+	if err := w.createCheckRun(ctx, p, result); err != nil {
+		w.logger.Errorw("error reporting check run", "organization", p.Organization, "repository", p.Repository, "head_sha", p.HeadSHA, "error", err)
+	}
+}
+
+func (w *WorkflowAction) createCheckRun(ctx context.Context, p *WorkflowActionParams, result *ValidationResult) error {
+	repoClient, err := w.client.ForRepository(p.Organization, p.Repository)
+	if err != nil {
+		return err
+	}
+	checks := repoClient.GetV3Client().Checks
+
+	key := w.workflowKey(p.Organization, p.Repository, p.WorkflowID)
+	record := &checkRun{}
+	found, err := w.state.Get(utils.StateBucketCheckRuns, key, record)
+	if err != nil {
+		return err
+	}
+
+	detailsURL := w.registrationURL()
+	output := blockedOutput(p, result, detailsURL)
+	if found && record.HeadSHA == p.HeadSHA {
+		_, _, err := checks.UpdateCheckRun(ctx, p.Organization, p.Repository, record.ID, github.UpdateCheckRunOptions{
+			Name:        w.checkRuns.Name,
+			DetailsURL:  github.String(detailsURL),
+			Status:      github.String(utils.WorkflowRunStatusCompleted),
+			Conclusion:  github.String(w.checkRuns.Conclusion),
+			CompletedAt: &github.Timestamp{Time: time.Now()},
+			Output:      output,
+		})
+		return err
+	}
+	if found {
+		// the record is replaced below, complete the check run of the previous commit while its ID is known
+		_, resp, err := checks.UpdateCheckRun(ctx, p.Organization, p.Repository, record.ID, github.UpdateCheckRunOptions{
+			Name:        w.checkRuns.Name,
+			Status:      github.String(utils.WorkflowRunStatusCompleted),
+			Conclusion:  github.String(utils.CheckRunConclusionNeutral),
+			CompletedAt: &github.Timestamp{Time: time.Now()},
+			Output:      supersededOutput(record, p.HeadSHA),
+		})
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return err
+		}
+	}
+
+	run, _, err := checks.CreateCheckRun(ctx, p.Organization, p.Repository, github.CreateCheckRunOptions{
+		Name:        w.checkRuns.Name,
+		HeadSHA:     p.HeadSHA,
+		DetailsURL:  github.String(detailsURL),
+		ExternalID:  github.String(key),
+		Status:      github.String(utils.WorkflowRunStatusCompleted),
+		Conclusion:  github.String(w.checkRuns.Conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output:      output,
+	})
+	if err != nil {
+		return err
+	}
+	w.logger.Infow("check run created", "organization", p.Organization, "repository", p.Repository, "head_sha", p.HeadSHA, "check_run_id", run.GetID())
+
+	return w.state.Put(utils.StateBucketCheckRuns, key, &checkRun{
+		Organization: p.Organization,
+		Repository:   p.Repository,
+		WorkflowID:   p.WorkflowID,
+		WorkflowName: p.WorkflowName,
+		HeadSHA:      p.HeadSHA,
+		ID:           run.GetID(),
+		CreatedAt:    time.Now().UTC(),
+	})
+}
+
+// resolveCheckRun marks the check run of a remediated workflow as successful
+func (w *WorkflowAction) resolveCheckRun(ctx context.Context, org, repo string, workflowID int64) error {
+	key := w.workflowKey(org, repo, workflowID)
+	record := &checkRun{}
+	found, err := w.state.Get(utils.StateBucketCheckRuns, key, record)
+	if err != nil || !found {
+		return err
+	}
+
+	repoClient, err := w.client.ForRepository(org, repo)
+	if err != nil {
+		return err
+	}
+	if _, _, err := repoClient.GetV3Client().Checks.UpdateCheckRun(ctx, org, repo, record.ID, github.UpdateCheckRunOptions{
+		Name:        w.checkRuns.Name,
+		Status:      github.String(utils.WorkflowRunStatusCompleted),
+		Conclusion:  github.String(utils.CheckRunConclusionSuccess),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output:      resolvedOutput(record),
+	}); err != nil {
+		return err
+	}
+	w.logger.Infow("check run resolved", "organization", org, "repository", repo, "head_sha", record.HeadSHA, "check_run_id", record.ID)
+
+	return w.state.Delete(utils.StateBucketCheckRuns, key)
+}
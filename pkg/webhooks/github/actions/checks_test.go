@@ -0,0 +1,124 @@
+package actions
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/state"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestNewCheckRuns(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.CheckRunsConfig
+		want    config.CheckRunsConfig
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			config: config.CheckRunsConfig{Enabled: true},
+			want:   config.CheckRunsConfig{Enabled: true, Name: utils.DefaultCheckRunName, Conclusion: utils.CheckRunConclusionFailure},
+		},
+		{
+			name:   "action required",
+			config: config.CheckRunsConfig{Name: "registration", Conclusion: "action_required"},
+			want:   config.CheckRunsConfig{Name: "registration", Conclusion: utils.CheckRunConclusionActionRequired},
+		},
+		{name: "invalid conclusion", config: config.CheckRunsConfig{Conclusion: "neutral"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newCheckRuns(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newCheckRuns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("newCheckRuns() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockedOutput(t *testing.T) {
+	p := &WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template", WorkflowName: "build"}
+	result := &ValidationResult{Organization: "mo-octocat", Repository: "flutter-template"}
+
+	output := blockedOutput(p, result, "https://octodemo.com/mo-octocat/actions-control")
+	if got := output.GetTitle(); got != "Workflow build is blocked" {
+		t.Errorf("title = %q", got)
+	}
+	for _, want := range []string{
+		"blocked workflow `build` of mo-octocat/flutter-template: no valid files found in repository mo-octocat/flutter-template",
+		"[registration instructions](https://octodemo.com/mo-octocat/actions-control)",
+		"No registration files were found.",
+	} {
+		if !strings.Contains(output.GetSummary(), want) {
+			t.Errorf("summary does not contain %q:\n%s", want, output.GetSummary())
+		}
+	}
+
+	if got := blockedOutput(p, nil, "").GetSummary(); !strings.Contains(got, "mo-octocat/flutter-template: the repository is not registered") {
+		t.Errorf("summary without validation result = %q", got)
+	}
+
+	result.Errors = []string{strings.Repeat("x", utils.MaxCheckRunSummaryLength)}
+	if got := len(blockedOutput(p, result, "").GetSummary()); got != utils.MaxCheckRunSummaryLength {
+		t.Errorf("summary length = %d, want %d", got, utils.MaxCheckRunSummaryLength)
+	}
+
+	// multi-byte characters are not cut in half, whichever byte the limit falls on
+	for _, prefix := range []string{"", "x"} {
+		result.Errors = []string{prefix + strings.Repeat("ü", utils.MaxCheckRunSummaryLength)}
+		summary := blockedOutput(p, result, "").GetSummary()
+		if !utf8.ValidString(summary) || len(summary) > utils.MaxCheckRunSummaryLength || len(summary) < utils.MaxCheckRunSummaryLength-utf8.UTFMax {
+			t.Errorf("summary of %d bytes, valid UTF-8 = %v", len(summary), utf8.ValidString(summary))
+		}
+	}
+}
+
+func TestWorkflowAction_resolveCheckRun_noRecord(t *testing.T) {
+	s, err := state.New(zap.NewNop().Sugar(), nil)
+	if err != nil {
+		t.Fatalf("state.New() error = %v", err)
+	}
+	w := &WorkflowAction{logger: zap.NewNop().Sugar(), organization: "mo-octocat", repository: "actions-control", state: s}
+
+	if err := w.resolveCheckRun(context.Background(), "mo-octocat", "flutter-template", 42); err != nil {
+		t.Errorf("resolveCheckRun() error = %v", err)
+	}
+}
+
+func TestWorkflowAction_createCheckRun_newCommit(t *testing.T) {
+	w := newTestWorkflowAction(t, map[string]any{"check_runs": map[string]any{"enabled": true}}, nil)
+	w.fake.reply(http.MethodPatch, "/repos/mo-octocat/flutter-template/check-runs/5", http.StatusOK, map[string]any{"id": 5})
+	w.fake.reply(http.MethodPost, "/repos/mo-octocat/flutter-template/check-runs", http.StatusCreated, map[string]any{"id": 6})
+
+	key := w.workflowKey(testOrganization, "flutter-template", 42)
+	if err := w.state.Put(utils.StateBucketCheckRuns, key, &checkRun{Organization: testOrganization, Repository: "flutter-template", WorkflowID: 42, WorkflowName: "build", HeadSHA: "aaa", ID: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &WorkflowActionParams{Organization: testOrganization, Repository: "flutter-template", WorkflowID: 42, WorkflowName: "build", HeadSHA: "bbb"}
+	if err := w.createCheckRun(context.Background(), p, &ValidationResult{}); err != nil {
+		t.Fatalf("createCheckRun() error = %v", err)
+	}
+
+	previous := w.fake.called(http.MethodPatch, "/repos/mo-octocat/flutter-template/check-runs/5")
+	if len(previous) != 1 || previous[0].Body["conclusion"] != utils.CheckRunConclusionNeutral {
+		t.Errorf("check run of the previous commit not completed as neutral: %+v", previous)
+	}
+	record := &checkRun{}
+	if _, err := w.state.Get(utils.StateBucketCheckRuns, key, record); err != nil {
+		t.Fatal(err)
+	}
+	if record.ID != 6 || record.HeadSHA != "bbb" {
+		t.Errorf("check run record = %+v, want check run 6 on bbb", record)
+	}
+}
@@ -0,0 +1,90 @@
+package actions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// codeownersPaths are the locations GitHub reads the CODEOWNERS file from, in order
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+type codeownersRule struct {
+	pattern string
+	owners  []string
+}
+
+func parseCodeowners(content string) []codeownersRule {
+	var rules []codeownersRule
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rules = append(rules, codeownersRule{pattern: fields[0], owners: fields[1:]})
+	}
+	return rules
+}
+
+// matches is a simplified version of the gitignore matching that CODEOWNERS uses. Patterns containing a slash
+// are anchored to the repository root, others match at any depth; a pattern matches the path itself or a
+// directory containing it.
+func (r codeownersRule) matches(filePath string) bool {
+	pattern := strings.TrimSuffix(r.pattern, "/")
+	if pattern == "*" || pattern == "" {
+		return true
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/**")
+
+	segments := strings.Split(filePath, "/")
+	for i := range segments {
+		if anchored && i > 0 {
+			break
+		}
+		for j := i + 1; j <= len(segments); j++ {
+			if ok, _ := path.Match(pattern, strings.Join(segments[i:j], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// codeownersFor returns the owners of the last rule matching the path
+func codeownersFor(rules []codeownersRule, filePath string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].matches(filePath) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// codeowners returns the owners of the workflow file in the repository, nil when it has no CODEOWNERS file
+func (w *WorkflowAction) codeowners(ctx context.Context, r *issueRepository, workflowPath string) ([]string, error) {
+	for _, filePath := range codeownersPaths {
+		content, resp, err := r.client.GetV3Client().Repositories.DownloadContents(ctx, r.owner, r.name, filePath, &github.RepositoryContentGetOptions{})
+		if err != nil {
+			if (resp != nil && resp.StatusCode == http.StatusNotFound) || strings.Contains(err.Error(), "no file named") {
+				continue
+			}
+			return nil, err
+		}
+		bytes, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		return codeownersFor(parseCodeowners(string(bytes)), workflowPath), nil
+	}
+	return nil, nil
+}
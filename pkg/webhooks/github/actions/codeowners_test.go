@@ -0,0 +1,34 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+)
+
+const codeowners = `# default owners
+*                 @mo-octocat/platform @octocat
+/.github/         @mouismail ops@example.com
+docs/             @writer
+*.yml             @yaml-owner   # configuration files
+`
+
+func TestCodeownersFor(t *testing.T) {
+	rules := parseCodeowners(codeowners)
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: ".github/workflows/build.yml", want: []string{"@yaml-owner"}},
+		{path: ".github/dependabot.json", want: []string{"@mouismail", "ops@example.com"}},
+		{path: "src/docs/readme.md", want: []string{"@writer"}},
+		{path: "main.go", want: []string{"@mo-octocat/platform", "@octocat"}},
+		{path: "", want: []string{"@mo-octocat/platform", "@octocat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := codeownersFor(rules, tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("codeownersFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,21 +5,48 @@ import (
 	"fmt"
 
 	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/breaker"
+	"github.tools.sap/actions-rollout-app/pkg/notify"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/pkg/state"
 	"github.tools.sap/actions-rollout-app/utils"
 
 	ghwebhooks "github.com/go-playground/webhooks/v6/github"
+	"github.com/mitchellh/mapstructure"
 	"github.tools.sap/actions-rollout-app/pkg/clients"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
 
+// Dependencies are the process wide facilities shared by all webhook actions
+type Dependencies struct {
+	Audit       *audit.Recorder
+	State       *state.Store
+	Breaker     *breaker.Breaker
+	Notifier    *notify.Dispatcher
+	Enforcement config.Enforcement
+}
+
 type WebhookActions struct {
 	logger          *zap.SugaredLogger
 	workflowActions []*WorkflowAction
 	repoActions     []*RepoAction
+	allowedActions  []*AllowedActionsAction
 }
 
-func InitActions(logger *zap.SugaredLogger, cs clients.ClientMap, config config.WebhookActions) (*WebhookActions, error) {
+func InitActions(logger *zap.SugaredLogger, cs clients.ClientMap, config config.WebhookActions, deps *Dependencies) (*WebhookActions, error) {
+	if deps == nil {
+		deps = &Dependencies{}
+	}
+	if deps.State == nil {
+		s, err := state.New(logger, nil)
+		if err != nil {
+			return nil, err
+		}
+		deps.State = s
+	}
+
 	actions := WebhookActions{
 		logger: logger,
 	}
@@ -44,7 +71,7 @@ func InitActions(logger *zap.SugaredLogger, cs clients.ClientMap, config config.
 		//	}
 		//	actions.issueActions = append(actions.issueActions, h)
 		case utils.ActionWorkflowHandler:
-			h, err := NewWorkflowAction(logger, c.(*clients.Github), spec.Args)
+			h, err := NewWorkflowAction(logger, c.(*clients.Github), spec.Args, deps)
 			if err != nil {
 				return nil, err
 			}
@@ -55,6 +82,12 @@ func InitActions(logger *zap.SugaredLogger, cs clients.ClientMap, config config.
 				return nil, err
 			}
 			actions.repoActions = append(actions.repoActions, h)
+		case utils.ActionAllowedActionsHandler:
+			h, err := NewAllowedActionsAction(logger, c.(*clients.Github), spec.Args, deps)
+			if err != nil {
+				return nil, err
+			}
+			actions.allowedActions = append(actions.allowedActions, h)
 		default:
 			return nil, fmt.Errorf(utils.ErrUnsupportedType, t)
 		}
@@ -65,6 +98,56 @@ func InitActions(logger *zap.SugaredLogger, cs clients.ClientMap, config config.
 	return &actions, nil
 }
 
+// Jobs returns the scheduled jobs of all configured actions
+func (w *WebhookActions) Jobs() []scheduler.Job {
+	var jobs []scheduler.Job
+	for _, wa := range w.workflowActions {
+		jobs = append(jobs, wa.jobs()...)
+	}
+	for _, aa := range w.allowedActions {
+		jobs = append(jobs, aa.jobs()...)
+	}
+	return jobs
+}
+
+// decodeArg decodes the optional action argument key into out, leaving out untouched when the key is not set
+func decodeArg(rawConfig map[string]any, key string, out any) error {
+	raw, ok := rawConfig[key]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	if err := decode(raw, out); err != nil {
+		return fmt.Errorf(utils.ErrInvalidActionArg, key, err)
+	}
+	return nil
+}
+
+// decodeArgs decodes all action arguments into out
+func decodeArgs(rawConfig map[string]any, out any) error {
+	if rawConfig == nil {
+		return nil
+	}
+
+	if err := decode(rawConfig, out); err != nil {
+		return fmt.Errorf(utils.ErrInvalidActionArgs, err)
+	}
+	return nil
+}
+
+func decode(raw any, out any) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(mapstructure.StringToTimeDurationHookFunc(), stringToIssueLabelHook),
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(raw)
+}
+
 func (w *WebhookActions) ProcessWorkflowDispatchEvent(payload *ghwebhooks.WorkflowDispatchPayload) {
 	ctx, cancel := context.WithTimeout(context.Background(), utils.WebhookHandleTimeout)
 	defer cancel()
@@ -110,7 +193,20 @@ func (w *WebhookActions) ProcessWorkflowJobEvent(payload *ghwebhooks.WorkflowJob
 				WorkflowName: payload.WorkflowJob.Name,
 				WorkflowID:   payload.WorkflowJob.ID,
 				WebhookEvent: ghwebhooks.WorkflowDispatchEvent,
+				Action:       payload.Action,
 				Sender:       payload.Sender.Login,
+				SenderType:   payload.Sender.Type,
+				RunID:        payload.WorkflowJob.RunID,
+				HeadSHA:      payload.WorkflowJob.HeadSha,
+				Repo: RepositoryMetadata{
+					ID:            payload.Repository.ID,
+					FullName:      payload.Repository.FullName,
+					Private:       payload.Repository.Private,
+					Visibility:    payload.Repository.Visibility,
+					DefaultBranch: payload.Repository.DefaultBranch,
+					Archived:      payload.Repository.Archived,
+					Fork:          payload.Repository.Fork,
+				},
 			}
 			err := wa.handleWorkflowJob(ctx, params)
 			if err != nil {
@@ -121,6 +217,14 @@ func (w *WebhookActions) ProcessWorkflowJobEvent(payload *ghwebhooks.WorkflowJob
 		})
 	}
 
+	for _, aa := range w.allowedActions {
+		aa := aa
+		group.Go(func() error {
+			aa.HandleEvent(ctx, payload.Organization.Login, payload.Repository.Name)
+			return nil
+		})
+	}
+
 	if err := group.Wait(); err != nil {
 		w.logger.Errorw(utils.LoggerErrorProcessingEvent, "error", err)
 	}
@@ -139,8 +243,23 @@ func (w *WebhookActions) ProcessWorkflowRunEvent(payload *ghwebhooks.WorkflowRun
 				Organization: payload.Organization.Login,
 				WorkflowName: payload.Workflow.Name,
 				WorkflowID:   payload.Workflow.ID,
+				WorkflowPath: payload.Workflow.Path,
 				WebhookEvent: ghwebhooks.WorkflowRunEvent,
+				Action:       payload.Action,
 				Sender:       payload.Sender.Login,
+				SenderType:   payload.Sender.Type,
+				RunID:        payload.WorkflowRun.ID,
+				HeadBranch:   payload.WorkflowRun.HeadBranch,
+				HeadSHA:      payload.WorkflowRun.HeadSha,
+				Repo: RepositoryMetadata{
+					ID:            payload.Repository.ID,
+					FullName:      payload.Repository.FullName,
+					Private:       payload.Repository.Private,
+					Visibility:    payload.Repository.Visibility,
+					DefaultBranch: payload.Repository.DefaultBranch,
+					Archived:      payload.Repository.Archived,
+					Fork:          payload.Repository.Fork,
+				},
 			}
 
 			err := wa.handleWorkflowRun(ctx, params)
@@ -153,6 +272,14 @@ func (w *WebhookActions) ProcessWorkflowRunEvent(payload *ghwebhooks.WorkflowRun
 		})
 	}
 
+	for _, aa := range w.allowedActions {
+		aa := aa
+		group.Go(func() error {
+			aa.HandleEvent(ctx, payload.Organization.Login, payload.Repository.Name)
+			return nil
+		})
+	}
+
 	if err := group.Wait(); err != nil {
 		w.logger.Errorw(utils.LoggerErrorProcessingEvent, "error", err)
 	}
@@ -7,6 +7,7 @@ import (
 	"go.uber.org/zap"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestInitActions(t *testing.T) {
@@ -14,6 +15,7 @@ func TestInitActions(t *testing.T) {
 		logger *zap.SugaredLogger
 		cs     clients.ClientMap
 		config config.WebhookActions
+		deps   *Dependencies
 	}
 	var tests []struct {
 		name    string
@@ -23,7 +25,7 @@ func TestInitActions(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := InitActions(tt.args.logger, tt.args.cs, tt.args.config)
+			got, err := InitActions(tt.args.logger, tt.args.cs, tt.args.config, tt.args.deps)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("InitActions() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -106,3 +108,45 @@ func TestWebhookActions_ProcessWorkflowRunEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeArg(t *testing.T) {
+	tests := []struct {
+		name      string
+		rawConfig map[string]any
+		want      config.RegistrationExpiryConfig
+		wantErr   bool
+	}{
+		{
+			name:      "missing key",
+			rawConfig: map[string]any{},
+			want:      config.RegistrationExpiryConfig{},
+		},
+		{
+			name: "decodes durations and numbers",
+			rawConfig: map[string]any{"registration_expiry": map[string]any{
+				"grace_period":      "336h",
+				"reminder_days":     float64(30),
+				"reminder_interval": "24h",
+			}},
+			want: config.RegistrationExpiryConfig{GracePeriod: 336 * time.Hour, ReminderDays: 30, ReminderInterval: 24 * time.Hour},
+		},
+		{
+			name:      "unknown field",
+			rawConfig: map[string]any{"registration_expiry": map[string]any{"grace": "1h"}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got config.RegistrationExpiryConfig
+			err := decodeArg(tt.rawConfig, "registration_expiry", &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeArg() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeArg() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
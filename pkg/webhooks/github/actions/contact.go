@@ -0,0 +1,334 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// orgDirectory resolves organization members, teams and member emails
+type orgDirectory interface {
+	ListMembers(ctx context.Context, org string) ([]string, error)
+	EmailLogins(ctx context.Context, org string) (map[string]string, error)
+	TeamExists(ctx context.Context, org, slug string) (bool, error)
+}
+
+var (
+	errContactNotMember = errors.New(utils.ErrContactNotOrgMember)
+	// errContactUnverified is returned for email contacts that no reliable source maps to a member, which does
+	// not prove that the contact has left
+	errContactUnverified = errors.New(utils.ErrContactEmailUnverified)
+)
+
+// githubAPI is the part of the GitHub client the directory queries
+type githubAPI interface {
+	GetV3Client() *github.Client
+}
+
+type githubDirectory struct {
+	client githubAPI
+}
+
+func (d *githubDirectory) ListMembers(ctx context.Context, org string) ([]string, error) {
+	var logins []string
+	opts := &github.ListMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		members, resp, err := d.client.GetV3Client().Organizations.ListMembers(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			logins = append(logins, strings.ToLower(member.GetLogin()))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return logins, nil
+}
+
+// EmailLogins maps the emails of the organization members to their logins. Emails are taken from the SAML
+// identities of the organization and from the emails of the members in its verified domains. Public profile
+// emails are not used, most members do not publish one and anyone can set any address.
+func (d *githubDirectory) EmailLogins(ctx context.Context, org string) (map[string]string, error) {
+	logins := make(map[string]string)
+	add := func(email, login string) {
+		if email != "" && login != "" {
+			logins[strings.ToLower(email)] = strings.ToLower(login)
+		}
+	}
+
+	var cursor *string
+	for {
+		var data struct {
+			Organization struct {
+				SamlIdentityProvider *struct {
+					ExternalIdentities struct {
+						PageInfo graphQLPageInfo `json:"pageInfo"`
+						Nodes    []struct {
+							SamlIdentity struct {
+								NameID string `json:"nameId"`
+								Emails []struct {
+									Value string `json:"value"`
+								} `json:"emails"`
+							} `json:"samlIdentity"`
+							User *struct {
+								Login string `json:"login"`
+							} `json:"user"`
+						} `json:"nodes"`
+					} `json:"externalIdentities"`
+				} `json:"samlIdentityProvider"`
+			} `json:"organization"`
+		}
+		if err := graphQL(ctx, d.client, samlIdentitiesQuery, map[string]any{"org": org, "cursor": cursor}, &data); err != nil {
+			return nil, err
+		}
+		provider := data.Organization.SamlIdentityProvider
+		if provider == nil {
+			break
+		}
+		for _, node := range provider.ExternalIdentities.Nodes {
+			if node.User == nil {
+				continue
+			}
+			if strings.Contains(node.SamlIdentity.NameID, "@") {
+				add(node.SamlIdentity.NameID, node.User.Login)
+			}
+			for _, email := range node.SamlIdentity.Emails {
+				add(email.Value, node.User.Login)
+			}
+		}
+		if !provider.ExternalIdentities.PageInfo.HasNextPage {
+			break
+		}
+		cursor = &provider.ExternalIdentities.PageInfo.EndCursor
+	}
+
+	cursor = nil
+	for {
+		var data struct {
+			Organization struct {
+				MembersWithRole struct {
+					PageInfo graphQLPageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						Login                            string   `json:"login"`
+						OrganizationVerifiedDomainEmails []string `json:"organizationVerifiedDomainEmails"`
+					} `json:"nodes"`
+				} `json:"membersWithRole"`
+			} `json:"organization"`
+		}
+		if err := graphQL(ctx, d.client, verifiedDomainEmailsQuery, map[string]any{"org": org, "cursor": cursor}, &data); err != nil {
+			return nil, err
+		}
+		members := data.Organization.MembersWithRole
+		for _, node := range members.Nodes {
+			for _, email := range node.OrganizationVerifiedDomainEmails {
+				add(email, node.Login)
+			}
+		}
+		if !members.PageInfo.HasNextPage {
+			break
+		}
+		cursor = &members.PageInfo.EndCursor
+	}
+
+	return logins, nil
+}
+
+func (d *githubDirectory) TeamExists(ctx context.Context, org, slug string) (bool, error) {
+	_, resp, err := d.client.GetV3Client().Teams.GetTeamBySlug(ctx, org, slug)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+type cachedMembers struct {
+	logins  map[string]bool
+	fetched time.Time
+}
+
+type cachedEmails struct {
+	logins  map[string]string
+	fetched time.Time
+}
+
+// contactVerifier checks that a registration contact maps to an active organization member or team
+type contactVerifier struct {
+	directory orgDirectory
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	members map[string]cachedMembers
+	emails  map[string]cachedEmails
+}
+
+func newContactVerifier(directory orgDirectory, ttl time.Duration) *contactVerifier {
+	if ttl <= 0 {
+		ttl = utils.DefaultContactCacheTTL
+	}
+	return &contactVerifier{
+		directory: directory,
+		ttl:       ttl,
+		now:       time.Now,
+		members:   make(map[string]cachedMembers),
+		emails:    make(map[string]cachedEmails),
+	}
+}
+
+// Verify returns an error when contact is neither a member nor a team of org. The contact can be
+// an email address, a GitHub login (optionally prefixed with @) or a team given as org/team-slug.
+// Emails that cannot be mapped to a member return errContactUnverified.
+func (c *contactVerifier) Verify(ctx context.Context, org, contact string) error {
+	contact = strings.TrimPrefix(strings.TrimSpace(contact), "@")
+
+	if teamOrg, slug, ok := strings.Cut(contact, "/"); ok {
+		if !strings.EqualFold(teamOrg, org) {
+			return fmt.Errorf("%w: %s", errContactNotMember, contact)
+		}
+		exists, err := c.directory.TeamExists(ctx, org, slug)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s", errContactNotMember, contact)
+		}
+		return nil
+	}
+
+	login := strings.ToLower(contact)
+	if strings.Contains(contact, "@") {
+		emails, err := c.emailLogins(ctx, org)
+		if err != nil {
+			return err
+		}
+		login = emails[strings.ToLower(contact)]
+		if login == "" {
+			return fmt.Errorf("%w: %s", errContactUnverified, contact)
+		}
+	}
+
+	members, err := c.orgMembers(ctx, org)
+	if err != nil {
+		return err
+	}
+	if !members[login] {
+		return fmt.Errorf("%w: %s", errContactNotMember, contact)
+	}
+
+	return nil
+}
+
+func (c *contactVerifier) orgMembers(ctx context.Context, org string) (map[string]bool, error) {
+	c.mu.Lock()
+	cached, ok := c.members[org]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetched) < c.ttl {
+		return cached.logins, nil
+	}
+
+	logins, err := c.directory.ListMembers(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	cached = cachedMembers{logins: make(map[string]bool, len(logins)), fetched: c.now()}
+	for _, login := range logins {
+		cached.logins[strings.ToLower(login)] = true
+	}
+
+	c.mu.Lock()
+	c.members[org] = cached
+	c.mu.Unlock()
+
+	return cached.logins, nil
+}
+
+func (c *contactVerifier) emailLogins(ctx context.Context, org string) (map[string]string, error) {
+	c.mu.Lock()
+	cached, ok := c.emails[org]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetched) < c.ttl {
+		return cached.logins, nil
+	}
+
+	logins, err := c.directory.EmailLogins(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.emails[org] = cachedEmails{logins: logins, fetched: c.now()}
+	c.mu.Unlock()
+
+	return logins, nil
+}
+
+type graphQLPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+const samlIdentitiesQuery = `query($org: String!, $cursor: String) {
+  organization(login: $org) {
+    samlIdentityProvider {
+      externalIdentities(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes { samlIdentity { nameId emails { value } } user { login } }
+      }
+    }
+  }
+}`
+
+const verifiedDomainEmailsQuery = `query($org: String!, $cursor: String) {
+  organization(login: $org) {
+    membersWithRole(first: 100, after: $cursor) {
+      pageInfo { hasNextPage endCursor }
+      nodes { login organizationVerifiedDomainEmails(login: $org) }
+    }
+  }
+}`
+
+// graphQL runs a query against the GraphQL API of the enterprise and decodes its data into out
+func graphQL(ctx context.Context, client githubAPI, query string, variables map[string]any, out any) error {
+	v3 := client.GetV3Client()
+	req, err := v3.NewRequest(http.MethodPost, "../graphql", map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := v3.Do(ctx, req, &response); err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		return errors.New(response.Errors[0].Message)
+	}
+	if out == nil || len(response.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(response.Data, out)
+}
@@ -0,0 +1,173 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+type fakeDirectory struct {
+	members     map[string][]string
+	emails      map[string]string
+	teams       map[string]bool
+	memberCalls int
+}
+
+func (f *fakeDirectory) ListMembers(_ context.Context, org string) ([]string, error) {
+	f.memberCalls++
+	return f.members[org], nil
+}
+
+func (f *fakeDirectory) EmailLogins(_ context.Context, _ string) (map[string]string, error) {
+	return f.emails, nil
+}
+
+func (f *fakeDirectory) TeamExists(_ context.Context, org, slug string) (bool, error) {
+	return f.teams[org+"/"+slug], nil
+}
+
+func TestContactVerifier_Verify(t *testing.T) {
+	directory := &fakeDirectory{
+		members: map[string][]string{"mo-octocat": {"MoUismail", "octocat"}},
+		emails:  map[string]string{"mo@example.com": "mouismail", "gone@example.com": "ghost"},
+		teams:   map[string]bool{"mo-octocat/platform": true},
+	}
+	tests := []struct {
+		name           string
+		org            string
+		contact        string
+		wantErr        bool
+		wantUnverified bool
+	}{
+		{name: "member login", org: "mo-octocat", contact: "octocat", wantErr: false},
+		{name: "member login with at sign and case", org: "mo-octocat", contact: "@mouismail", wantErr: false},
+		{name: "member email", org: "mo-octocat", contact: "Mo@example.com", wantErr: false},
+		{name: "departed email", org: "mo-octocat", contact: "gone@example.com", wantErr: true},
+		{name: "unknown email", org: "mo-octocat", contact: "nobody@example.com", wantErr: true, wantUnverified: true},
+		{name: "non member login", org: "mo-octocat", contact: "ghost", wantErr: true},
+		{name: "team", org: "mo-octocat", contact: "mo-octocat/platform", wantErr: false},
+		{name: "missing team", org: "mo-octocat", contact: "mo-octocat/legacy", wantErr: true},
+		{name: "team of other org", org: "mo-octocat", contact: "other/platform", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newContactVerifier(directory, time.Hour)
+			err := c.Verify(context.Background(), tt.org, tt.contact)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, errContactUnverified) != tt.wantUnverified {
+				t.Errorf("Verify() error = %v, wantUnverified %v", err, tt.wantUnverified)
+			}
+		})
+	}
+}
+
+func TestContactVerifier_cache(t *testing.T) {
+	directory := &fakeDirectory{members: map[string][]string{"mo-octocat": {"octocat"}}}
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	c := newContactVerifier(directory, time.Hour)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if err := c.Verify(context.Background(), "mo-octocat", "octocat"); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	}
+	if directory.memberCalls != 1 {
+		t.Errorf("ListMembers() called %d times, want 1", directory.memberCalls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := c.Verify(context.Background(), "mo-octocat", "octocat"); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if directory.memberCalls != 2 {
+		t.Errorf("ListMembers() called %d times after expiry, want 2", directory.memberCalls)
+	}
+}
+
+func TestGithubDirectory_EmailLogins(t *testing.T) {
+	fake := newFakeGitHub(t)
+	fake.handle(http.MethodPost, "/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(request.Query, "externalIdentities") && request.Variables["cursor"] == nil:
+			_, _ = w.Write([]byte(`{"data": {"organization": {"samlIdentityProvider": {"externalIdentities": {
+				"pageInfo": {"hasNextPage": true, "endCursor": "next"},
+				"nodes": [
+					{"samlIdentity": {"nameId": "Mo@Example.com", "emails": []}, "user": {"login": "MoUismail"}},
+					{"samlIdentity": {"nameId": "unlinked@example.com", "emails": []}, "user": null}
+				]}}}}}`))
+		case strings.Contains(request.Query, "externalIdentities"):
+			_, _ = w.Write([]byte(`{"data": {"organization": {"samlIdentityProvider": {"externalIdentities": {
+				"pageInfo": {"hasNextPage": false, "endCursor": ""},
+				"nodes": [{"samlIdentity": {"nameId": "I123456", "emails": [{"value": "octo@example.com"}]}, "user": {"login": "octocat"}}]
+				}}}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data": {"organization": {"membersWithRole": {
+				"pageInfo": {"hasNextPage": false, "endCursor": ""},
+				"nodes": [{"login": "hubot", "organizationVerifiedDomainEmails": ["hubot@example.com"]}]
+				}}}}`))
+		}
+	})
+
+	directory := &githubDirectory{client: fake.api()}
+	got, err := directory.EmailLogins(context.Background(), "mo-octocat")
+	if err != nil {
+		t.Fatalf("EmailLogins() error = %v", err)
+	}
+	want := map[string]string{"mo@example.com": "mouismail", "octo@example.com": "octocat", "hubot@example.com": "hubot"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EmailLogins() = %v, want %v", got, want)
+	}
+	if calls := len(fake.called(http.MethodPost, "/graphql")); calls != 3 {
+		t.Errorf("graphql called %d times, want 3", calls)
+	}
+}
+
+func TestRepoAction_verifyContact(t *testing.T) {
+	directory := &fakeDirectory{
+		members: map[string][]string{"mo-octocat": {"octocat"}},
+		emails:  map[string]string{"gone@example.com": "ghost"},
+	}
+	tests := []struct {
+		name        string
+		failure     string
+		contact     string
+		wantWarning bool
+		wantErr     bool
+	}{
+		{name: "member", failure: utils.ContactFailureHard, contact: "octocat"},
+		{name: "departed soft", failure: utils.ContactFailureSoft, contact: "gone@example.com", wantWarning: true},
+		{name: "departed hard", failure: utils.ContactFailureHard, contact: "gone@example.com", wantErr: true},
+		{name: "unverified email hard", failure: utils.ContactFailureHard, contact: "nobody@example.com", wantWarning: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &RepoAction{logger: zap.NewNop().Sugar(), contactVerifier: newContactVerifier(directory, time.Hour), contactFailure: tt.failure}
+			params := &RepoActionParams{ValidationOrganization: "mo-octocat"}
+			warning, err := r.verifyContact(context.Background(), params, &ValidatorData{ContactEmail: tt.contact})
+			if (warning != "") != tt.wantWarning {
+				t.Errorf("verifyContact() warning = %q, wantWarning %v", warning, tt.wantWarning)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyContact() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/notify"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// digestTolerance lets a digest run a little early, scheduler ticks are not exact
+const digestTolerance = 5 * time.Minute
+
+// digestItem counts the decisions of one kind for a workflow
+type digestItem struct {
+	Repository string
+	Workflow   string
+	Action     string
+	Count      int
+}
+
+// digest summarizes the enforcement activity of an organization over a period
+type digest struct {
+	Organization string
+	Since        time.Time
+	Until        time.Time
+	Violations   []*digestItem
+	Disabled     []*digestItem
+	Remediated   []*digestItem
+	Expiring     []registrationFile
+}
+
+// newDigestConfig applies the defaults, digests are built from the audit log and can not be enabled without it
+func newDigestConfig(c config.DigestConfig, recorder *audit.Recorder) (config.DigestConfig, error) {
+	if c.Enabled && !recorder.Enabled() {
+		return c, errors.New(utils.ErrDigestWithoutAudit)
+	}
+	if c.Interval <= 0 {
+		c.Interval = utils.DefaultDigestInterval
+	}
+	if c.ExpiringDays <= 0 {
+		c.ExpiringDays = utils.DefaultDigestExpiringDays
+	}
+	if len(c.Deliveries) == 0 {
+		c.Deliveries = []string{utils.DigestDeliveryIssue}
+	}
+	if strings.Contains(c.Repository, "/") {
+		return c, fmt.Errorf(utils.ErrInvalidDigestRepository, c.Repository)
+	}
+	for _, delivery := range c.Deliveries {
+		if delivery != utils.DigestDeliveryIssue && delivery != utils.DigestDeliveryNotification {
+			return c, fmt.Errorf(utils.ErrInvalidDigestDelivery, delivery)
+		}
+	}
+	return c, nil
+}
+
+// newDigest builds the digest of the organization from the audit entries and the expiring registrations
+func newDigest(org string, since, until time.Time, entries []audit.Entry, expiring []registrationFile, enterpriseURL string) *digest {
+	d := &digest{Organization: org, Since: since, Until: until}
+	items := make(map[string]*digestItem)
+	add := func(list *[]*digestItem, e audit.Entry, action string) {
+		key := strings.Join([]string{action, e.Repository, e.WorkflowName}, "/")
+		item, ok := items[key]
+		if !ok {
+			item = &digestItem{Repository: e.Repository, Workflow: e.WorkflowName, Action: action}
+			items[key] = item
+			*list = append(*list, item)
+		}
+		item.Count++
+	}
+
+	for _, e := range entries {
+		if e.Organization != org || e.Time.Before(since) || !e.Time.Before(until) {
+			continue
+		}
+		switch e.Action {
+		case utils.AuditActionValidated:
+			if !e.Valid {
+				add(&d.Violations, e, e.Action)
+			}
+		case utils.AuditActionDisabled, utils.AuditActionRepositoryDisabled, utils.AuditActionOrganizationDisabled:
+			add(&d.Disabled, e, e.Action)
+		case utils.AuditActionReenabled, utils.AuditActionIssueClosed:
+			add(&d.Remediated, e, e.Action)
+		}
+	}
+	for _, list := range [][]*digestItem{d.Violations, d.Disabled, d.Remediated} {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Repository != list[j].Repository {
+				return list[i].Repository < list[j].Repository
+			}
+			return list[i].Workflow < list[j].Workflow
+		})
+	}
+
+	for _, registration := range expiring {
+		if strings.EqualFold(organizationFromURL(enterpriseURL, registration.Data.URL), org) {
+			d.Expiring = append(d.Expiring, registration)
+		}
+	}
+	return d
+}
+
+func (d *digest) empty() bool {
+	return len(d.Violations) == 0 && len(d.Disabled) == 0 && len(d.Remediated) == 0 && len(d.Expiring) == 0
+}
+
+// Text is the one line summary sent to the notification targets
+func (d *digest) Text() string {
+	return fmt.Sprintf(utils.DigestText, len(d.Violations), len(d.Disabled), len(d.Remediated), len(d.Expiring), d.Organization)
+}
+
+// Markdown renders the digest as the body of the digest issue, central is the repository of the registrations
+func (d *digest) Markdown(enterpriseURL, central string) string {
+	enterpriseURL = strings.TrimSuffix(enterpriseURL, "/")
+	repoLink := func(repo string) string {
+		return fmt.Sprintf("[%s](%s/%s/%s)", repo, enterpriseURL, d.Organization, repo)
+	}
+	workflow := func(item *digestItem) string {
+		if item.Workflow == "" {
+			return "-"
+		}
+		return item.Workflow
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Actions Controller digest for %s\n\n", d.Organization)
+	fmt.Fprintf(&b, "%s to %s\n", d.Since.UTC().Format(time.RFC1123), d.Until.UTC().Format(time.RFC1123))
+
+	fmt.Fprintf(&b, "\n### :red_circle: New violations (%d)\n", len(d.Violations))
+	if len(d.Violations) > 0 {
+		b.WriteString("| Repository | Workflow | Failed runs |\n| -----------|----------|-------------|\n")
+		for _, item := range d.Violations {
+			fmt.Fprintf(&b, "| %s | %s | %d |\n", repoLink(item.Repository), workflow(item), item.Count)
+		}
+	}
+
+	for _, section := range []struct {
+		title string
+		items []*digestItem
+	}{
+		{title: ":no_entry: Disabled workflows", items: d.Disabled},
+		{title: ":white_check_mark: Remediations", items: d.Remediated},
+	} {
+		fmt.Fprintf(&b, "\n### %s (%d)\n", section.title, len(section.items))
+		if len(section.items) == 0 {
+			continue
+		}
+		b.WriteString("| Repository | Workflow | Action |\n| -----------|----------|--------|\n")
+		for _, item := range section.items {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", repoLink(item.Repository), workflow(item), item.Action)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n### :hourglass: Expiring registrations (%d)\n", len(d.Expiring))
+	if len(d.Expiring) > 0 {
+		b.WriteString("| Registration | Expires | Owner | Contact |\n| -------------|---------|-------|---------|\n")
+		for _, registration := range d.Expiring {
+			owner := "-"
+			if registration.Data.Owner != "" {
+				owner = "@" + registration.Data.Owner
+			}
+			fmt.Fprintf(&b, "| [%s](%s) | %s | %s | %s |\n", registration.Path, registrationLink(enterpriseURL, central, registration), registration.Data.Expires, owner, registration.Data.ContactEmail)
+		}
+	}
+	return b.String()
+}
+
+// registrationLink links the registration file, in-repo registrations are paths of their own repository
+func registrationLink(enterpriseURL, central string, registration registrationFile) string {
+	if registration.Source == utils.RegistrationSourceRepository {
+		parts := strings.SplitN(registration.Path, "/", 3)
+		if len(parts) == 3 {
+			return fmt.Sprintf("%s/%s/%s/blob/HEAD/%s", enterpriseURL, parts[0], parts[1], parts[2])
+		}
+	}
+	return fmt.Sprintf("%s/%s/blob/main/%s", enterpriseURL, central, registration.Path)
+}
+
+func (w *WorkflowAction) digestOrganizations() []string {
+	if len(w.digest.Organizations) > 0 {
+		return w.digest.Organizations
+	}
+	return []string{w.organization}
+}
+
+func (w *WorkflowAction) delivers(delivery string) bool {
+	for _, d := range w.digest.Deliveries {
+		if d == delivery {
+			return true
+		}
+	}
+	return false
+}
+
+// digestSince returns the start of the next digest of the organization, due is false while the last digest is
+// younger than the interval. The period starts where the last digest ended, so that restarts leave no gaps; after
+// a longer outage it covers the last interval.
+func (w *WorkflowAction) digestSince(org string, now time.Time) (time.Time, bool, error) {
+	var last time.Time
+	found, err := w.state.Get(utils.StateBucketDigests, w.digestKey(org), &last)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if !found || last.Before(now.Add(-2*w.digest.Interval)) {
+		return now.Add(-w.digest.Interval), true, nil
+	}
+	return last, !now.Before(last.Add(w.digest.Interval - digestTolerance)), nil
+}
+
+func (w *WorkflowAction) digestKey(org string) string {
+	return fmt.Sprintf("%s/%s:%s", w.organization, w.repository, org)
+}
+
+// publishDigests publishes the digest of every organization that is due
+func (w *WorkflowAction) publishDigests(ctx context.Context) error {
+	now := time.Now().UTC()
+	enterpriseURL := w.client.ServerInfo().EnterpriseURL
+
+	// the same sources as the validation, so that in-repo registrations are listed as well
+	registrations, err := w.repoAction().sourceRegistrations(ctx, w.digestOrganizations(), false)
+	if err != nil {
+		return err
+	}
+	expiring := expiringRegistrations(registrations, now, w.digest.ExpiringDays)
+
+	var lastErr error
+	for _, org := range w.digestOrganizations() {
+		since, due, err := w.digestSince(org, now)
+		if err != nil {
+			return err
+		}
+		if !due {
+			continue
+		}
+		entries, err := w.audit.Entries(since)
+		if err != nil {
+			return err
+		}
+
+		d := newDigest(org, since, now, entries, expiring, enterpriseURL)
+		if err := w.deliverDigest(ctx, d); err != nil {
+			w.logger.Errorw("error publishing digest", "organization", org, "error", err)
+			lastErr = err
+			continue
+		}
+		w.logger.Infow("digest published", "organization", org, "since", since, "violations", len(d.Violations), "disabled", len(d.Disabled), "remediated", len(d.Remediated), "expiring", len(d.Expiring))
+
+		if err := w.state.Put(utils.StateBucketDigests, w.digestKey(org), now); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// deliverDigest updates the digest issue and sends the digest to the notification targets, empty digests only
+// update the issue
+func (w *WorkflowAction) deliverDigest(ctx context.Context, d *digest) error {
+	var issue *github.Issue
+	if w.delivers(utils.DigestDeliveryIssue) {
+		var err error
+		if issue, err = w.publishDigestIssue(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	if w.delivers(utils.DigestDeliveryNotification) && !d.empty() {
+		w.send(notify.Notification{
+			Kind:         utils.NotificationKindDigest,
+			Organization: d.Organization,
+			Contact:      w.digest.Contact,
+			Title:        fmt.Sprintf(utils.DigestTitle, d.Organization),
+			Text:         d.Text(),
+			URL:          issue.GetHTMLURL(),
+			Time:         d.Until,
+		})
+	}
+	return nil
+}
+
+// digestRepository is the repository of the digest issue of the organization, the configured repository or the
+// one named like the central repository within the organization
+func (w *WorkflowAction) digestRepository(org string) string {
+	name := w.digest.Repository
+	if name == "" {
+		_, name, _ = strings.Cut(w.centralRepository(), "/")
+	}
+	return fmt.Sprintf("%s/%s", org, name)
+}
+
+// publishDigestIssue replaces the body of the digest issue of the organization, the issue is opened and pinned
+// in the digest repository of the organization on the first digest
+func (w *WorkflowAction) publishDigestIssue(ctx context.Context, d *digest) (*github.Issue, error) {
+	r, err := w.issueRepository(w.digestRepository(d.Organization))
+	if err != nil {
+		return nil, err
+	}
+	marker := fmt.Sprintf(utils.DigestMarker, d.Organization)
+	body := d.Markdown(w.client.ServerInfo().EnterpriseURL, fmt.Sprintf("%s/%s", w.organization, w.repository)) + "\n" + marker + "\n"
+
+	issue, err := w.findIssue(ctx, r, utils.LabelDigest, marker)
+	if err != nil {
+		return nil, err
+	}
+	if issue != nil {
+		issue, _, err = r.client.GetV3Client().Issues.Edit(ctx, r.owner, r.name, issue.GetNumber(), &github.IssueRequest{
+			Body: github.String(body),
+		})
+		return issue, err
+	}
+
+	labels, err := w.ensureLabels(ctx, r, []config.IssueLabel{{Name: utils.LabelDigest, Color: utils.LabelDigestColor}})
+	if err != nil {
+		return nil, err
+	}
+	issue, err = w.createIssue(ctx, r, fmt.Sprintf(utils.DigestTitle, d.Organization), body, nil, labels)
+	if err != nil {
+		return nil, err
+	}
+	if err := pinIssue(ctx, r.client, issue.GetNodeID()); err != nil {
+		// repositories pin at most three issues, the digest is still updated unpinned
+		w.logger.Warnw("error pinning digest issue", "repository", r.String(), "issue", issue.GetNumber(), "error", err)
+	}
+	return issue, nil
+}
+
+// pinIssue pins the issue through the GraphQL API, the REST API cannot pin issues
+func pinIssue(ctx context.Context, client *clients.Github, nodeID string) error {
+	return graphQL(ctx, client, "mutation($id: ID!) { pinIssue(input: {issueId: $id}) { issue { id } } }", map[string]any{"id": nodeID}, nil)
+}
+
+func (w *WorkflowAction) digestJob() scheduler.Job {
+	return scheduler.Job{
+		Name:     fmt.Sprintf("digest-%s/%s", w.organization, w.repository),
+		Interval: w.digest.Interval,
+		Run:      w.publishDigests,
+	}
+}
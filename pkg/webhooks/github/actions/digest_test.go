@@ -0,0 +1,179 @@
+package actions
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/state"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestNewDigestConfig(t *testing.T) {
+	recorder := audit.New(zap.NewNop().Sugar(), &config.Audit{Path: filepath.Join(t.TempDir(), "audit.log")})
+	tests := []struct {
+		name     string
+		config   config.DigestConfig
+		recorder *audit.Recorder
+		wantErr  bool
+	}{
+		{name: "defaults", config: config.DigestConfig{Enabled: true}, recorder: recorder},
+		{name: "notification", config: config.DigestConfig{Deliveries: []string{"issue", "notification"}}},
+		{name: "unknown delivery", config: config.DigestConfig{Deliveries: []string{"fax"}}, wantErr: true},
+		{name: "repository with owner", config: config.DigestConfig{Repository: "mo-octocat/.github"}, wantErr: true},
+		{name: "without audit log", config: config.DigestConfig{Enabled: true}, recorder: audit.New(zap.NewNop().Sugar(), nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newDigestConfig(tt.config, tt.recorder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newDigestConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Interval != utils.DefaultDigestInterval || got.ExpiringDays != utils.DefaultDigestExpiringDays || len(got.Deliveries) == 0) {
+				t.Errorf("newDigestConfig() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestNewDigest(t *testing.T) {
+	until := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	since := until.Add(-24 * time.Hour)
+	entry := func(ago time.Duration, org, repo, workflow, action string, valid bool) audit.Entry {
+		return audit.Entry{Time: until.Add(-ago), Organization: org, Repository: repo, WorkflowName: workflow, Action: action, Valid: valid}
+	}
+	entries := []audit.Entry{
+		entry(48*time.Hour, "mo-octocat", "old", "build", utils.AuditActionValidated, false),
+		entry(3*time.Hour, "mo-octocat", "flutter-template", "build", utils.AuditActionValidated, false),
+		entry(2*time.Hour, "mo-octocat", "flutter-template", "build", utils.AuditActionValidated, false),
+		entry(2*time.Hour, "mo-octocat", "flutter-template", "build", utils.AuditActionDisabled, false),
+		entry(time.Hour, "mo-octocat", "app", "test", utils.AuditActionValidated, true),
+		entry(time.Hour, "mo-octocat", "app", "test", utils.AuditActionReenabled, true),
+		entry(time.Hour, "other", "app", "build", utils.AuditActionValidated, false),
+	}
+	expiring := []registrationFile{
+		{Path: "registrations/mo-octocat.yml", Data: ValidatorData{URL: "https://octodemo.com/mo-octocat", Owner: "mouismail", ContactEmail: "team@example.com", Expires: "2023-06-20"}},
+		{Path: "registrations/other.yml", Data: ValidatorData{URL: "https://octodemo.com/other", Expires: "2023-06-21"}},
+		{Path: "mo-octocat/app/.github/actions-registration.yml", Source: utils.RegistrationSourceRepository, Data: ValidatorData{URL: "https://octodemo.com/mo-octocat", Expires: "2023-06-22"}},
+	}
+
+	d := newDigest("mo-octocat", since, until, entries, expiring, "https://octodemo.com")
+	if len(d.Violations) != 1 || d.Violations[0].Repository != "flutter-template" || d.Violations[0].Count != 2 {
+		t.Errorf("violations = %+v", d.Violations)
+	}
+	if len(d.Disabled) != 1 || d.Disabled[0].Action != utils.AuditActionDisabled {
+		t.Errorf("disabled = %+v", d.Disabled)
+	}
+	if len(d.Remediated) != 1 || d.Remediated[0].Repository != "app" {
+		t.Errorf("remediated = %+v", d.Remediated)
+	}
+	if len(d.Expiring) != 2 || d.Expiring[0].Path != "registrations/mo-octocat.yml" {
+		t.Errorf("expiring = %+v", d.Expiring)
+	}
+	if d.empty() {
+		t.Errorf("empty() = true")
+	}
+
+	if got, want := d.Text(), "1 new violations, 1 disabled workflows, 1 remediations and 2 expiring registrations in mo-octocat"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+	markdown := d.Markdown("https://octodemo.com/", "mo-octocat/actions-control")
+	for _, want := range []string{
+		"## Actions Controller digest for mo-octocat",
+		"| [flutter-template](https://octodemo.com/mo-octocat/flutter-template) | build | 2 |",
+		"| [app](https://octodemo.com/mo-octocat/app) | test | re-enabled |",
+		"| [registrations/mo-octocat.yml](https://octodemo.com/mo-octocat/actions-control/blob/main/registrations/mo-octocat.yml) | 2023-06-20 | @mouismail | team@example.com |",
+		"| [mo-octocat/app/.github/actions-registration.yml](https://octodemo.com/mo-octocat/app/blob/HEAD/.github/actions-registration.yml) | 2023-06-22 |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown() does not contain %q:\n%s", want, markdown)
+		}
+	}
+
+	if !newDigest("quiet", since, until, entries, expiring, "https://octodemo.com").empty() {
+		t.Errorf("empty() = false for an organization without activity")
+	}
+}
+
+func TestWorkflowAction_digestSince(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		last      time.Time
+		wantSince time.Time
+		wantDue   bool
+	}{
+		{name: "first digest", wantSince: now.Add(-24 * time.Hour), wantDue: true},
+		{name: "published recently", last: now.Add(-time.Hour), wantSince: now.Add(-time.Hour), wantDue: false},
+		{name: "tick slightly early", last: now.Add(-24*time.Hour + time.Second), wantSince: now.Add(-24*time.Hour + time.Second), wantDue: true},
+		{name: "long outage", last: now.Add(-72 * time.Hour), wantSince: now.Add(-24 * time.Hour), wantDue: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := state.New(zap.NewNop().Sugar(), nil)
+			if err != nil {
+				t.Fatalf("state.New() error = %v", err)
+			}
+			w := &WorkflowAction{organization: "mo-octocat", repository: "actions-control", state: s, digest: config.DigestConfig{Interval: 24 * time.Hour}}
+			if !tt.last.IsZero() {
+				if err := s.Put(utils.StateBucketDigests, w.digestKey("mo-octocat"), tt.last); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			since, due, err := w.digestSince("mo-octocat", now)
+			if err != nil {
+				t.Fatalf("digestSince() error = %v", err)
+			}
+			if !since.Equal(tt.wantSince) || due != tt.wantDue {
+				t.Errorf("digestSince() = %v, %v, want %v, %v", since, due, tt.wantSince, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_publishDigests(t *testing.T) {
+	w := newTestWorkflowAction(t, map[string]any{
+		"digest":               map[string]any{"enabled": true, "organizations": []any{testOrganization, "other-org"}},
+		"registration_sources": map[string]any{"order": []any{utils.RegistrationSourceCentral, utils.RegistrationSourceRepository}},
+	}, nil)
+	w.fake.issues("other-org", testRepository)
+	w.fake.reply(http.MethodGet, "/orgs/mo-octocat/repos", http.StatusOK, []any{map[string]any{"id": 2, "name": "flutter-template"}})
+	w.fake.reply(http.MethodGet, "/orgs/other-org/repos", http.StatusOK, []any{})
+	inRepo := testRegistration + fmt.Sprintf("expires: %q\n", time.Now().AddDate(0, 0, 5).Format(utils.RegistrationExpiryLayout))
+	w.fake.reply(http.MethodGet, "/repos/mo-octocat/flutter-template/contents/.github/actions-registration.yml", http.StatusOK, map[string]any{
+		"type":     "file",
+		"encoding": "base64",
+		"content":  base64.StdEncoding.EncodeToString([]byte(inRepo)),
+	})
+
+	if err := w.publishDigests(context.Background()); err != nil {
+		t.Fatalf("publishDigests() error = %v", err)
+	}
+
+	// every organization keeps its digest issue in its own repository
+	for _, tt := range []struct {
+		org  string
+		want string
+	}{
+		{org: testOrganization, want: "mo-octocat/flutter-template/.github/actions-registration.yml"},
+		{org: "other-org", want: "### :hourglass: Expiring registrations (0)"},
+	} {
+		issues := w.fake.called(http.MethodPost, fmt.Sprintf("/repos/%s/%s/issues", tt.org, testRepository))
+		if len(issues) != 1 {
+			t.Fatalf("publishDigests() opened %d digest issues in %s, want 1", len(issues), tt.org)
+		}
+		if body, _ := issues[0].Body["body"].(string); !strings.Contains(body, tt.want) {
+			t.Errorf("digest of %s does not contain %q:\n%s", tt.org, tt.want, body)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/breaker"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func validateEnforcement(e config.Enforcement) error {
+	modes := []string{e.Mode}
+	for _, mode := range e.Organizations {
+		modes = append(modes, mode)
+	}
+	for _, mode := range modes {
+		if mode != "" && mode != utils.EnforcementModeEnforce && mode != utils.EnforcementModeAudit {
+			return fmt.Errorf(utils.ErrInvalidEnforcementMode, mode)
+		}
+	}
+
+	switch e.Scope {
+	case "", utils.EnforcementScopeWorkflow, utils.EnforcementScopeRepository:
+	case utils.EnforcementScopeOrganization:
+		if !e.ConfirmOrganization {
+			return fmt.Errorf(utils.ErrOrganizationScopeNotConfirmed)
+		}
+	default:
+		return fmt.Errorf(utils.ErrInvalidEnforcementScope, e.Scope)
+	}
+	return nil
+}
+
+// resolveEnforcementMode picks the most specific mode: the organization of the action, the global
+// organization, the action and finally the global mode. Without any configuration workflows are enforced.
+func resolveEnforcementMode(action, global config.Enforcement, org string) string {
+	for _, mode := range []string{action.Organizations[org], global.Organizations[org], action.Mode, global.Mode} {
+		if mode != "" {
+			return mode
+		}
+	}
+	return utils.EnforcementModeEnforce
+}
+
+// enforcementMode falls back to audit while the circuit breaker is open for the organization
+func (w *WorkflowAction) enforcementMode(org string) string {
+	if w.breaker.Tripped(org) {
+		return utils.EnforcementModeAudit
+	}
+	return resolveEnforcementMode(w.enforcement, w.globalEnforcement, org)
+}
+
+// alertCircuitBreaker opens an issue in the central repository when the circuit breaker trips
+func (w *WorkflowAction) alertCircuitBreaker(ctx context.Context, trip breaker.Trip) {
+	scope := trip.Scope
+	if scope == utils.CircuitBreakerGlobal {
+		scope = "all organizations"
+	}
+
+	title := fmt.Sprintf(utils.CircuitBreakerTrippedTitle, scope)
+	message := fmt.Sprintf(utils.CircuitBreakerTrippedMessage, trip.Count, trip.Window, scope)
+	if _, err := w.createWorkflowIssue(ctx, title, message, *w.assignees, []string{utils.LabelCircuitBreaker}); err != nil {
+		w.logger.Errorw("error creating circuit breaker issue", "scope", trip.Scope, "error", err)
+	}
+}
+
+// resolveEnforcementScope picks the scope of the action over the global one, by default only the workflow is disabled
+func resolveEnforcementScope(action, global config.Enforcement) string {
+	for _, scope := range []string{action.Scope, global.Scope} {
+		if scope != "" {
+			return scope
+		}
+	}
+	return utils.EnforcementScopeWorkflow
+}
+
+func (w *WorkflowAction) enforcementScope() string {
+	return resolveEnforcementScope(w.enforcement, w.globalEnforcement)
+}
+
+// enforce disables the workflows, the repository or the organization depending on the enforcement scope.
+// Only disabled workflows are recorded for re-enabling, repositories and organizations are restored by hand.
+func (w *WorkflowAction) enforce(ctx context.Context, p *WorkflowActionParams, workflows map[int64]string, result *ValidationResult, issue int) error {
+	switch scope := w.enforcementScope(); scope {
+	case utils.EnforcementScopeRepository:
+		if err := w.disableWorkflowForRepo(ctx, p, p.Repo.ID); err != nil {
+			return err
+		}
+		w.logger.Infow("repository removed from the actions allowlist", "organization", p.Organization, "repository", p.Repository)
+		w.recordAudit(p, utils.AuditActionRepositoryDisabled, result)
+	case utils.EnforcementScopeOrganization:
+		if err := w.disableWorkflowByOrganization(ctx, p); err != nil {
+			return err
+		}
+		w.logger.Warnw("actions disabled for the organization", "organization", p.Organization)
+		w.recordAudit(p, utils.AuditActionOrganizationDisabled, result)
+	default:
+		for workflowID, workflowName := range workflows {
+			params := *p
+			params.WorkflowID = workflowID
+			params.WorkflowName = workflowName
+			if err := w.disableWorkflow(ctx, &params, workflowID); err != nil {
+				return err
+			}
+			w.logger.Infow("workflow disabled", "workflow_id", workflowID)
+			w.recordAudit(&params, utils.AuditActionDisabled, result)
+			w.markDisabled(&params, result, issue)
+		}
+	}
+	w.reportCheckRun(ctx, p, result)
+	return nil
+}
@@ -0,0 +1,92 @@
+package actions
+
+import (
+	"testing"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+func TestResolveEnforcementMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		action config.Enforcement
+		global config.Enforcement
+		org    string
+		want   string
+	}{
+		{name: "default", org: "mo-octocat", want: "enforce"},
+		{name: "global", global: config.Enforcement{Mode: "audit"}, org: "mo-octocat", want: "audit"},
+		{name: "action overrides global", action: config.Enforcement{Mode: "enforce"}, global: config.Enforcement{Mode: "audit"}, org: "mo-octocat", want: "enforce"},
+		{
+			name:   "global organization overrides action",
+			action: config.Enforcement{Mode: "enforce"},
+			global: config.Enforcement{Organizations: map[string]string{"mo-octocat": "audit"}},
+			org:    "mo-octocat",
+			want:   "audit",
+		},
+		{
+			name:   "action organization overrides all",
+			action: config.Enforcement{Mode: "audit", Organizations: map[string]string{"mo-octocat": "enforce"}},
+			global: config.Enforcement{Mode: "audit", Organizations: map[string]string{"mo-octocat": "audit"}},
+			org:    "mo-octocat",
+			want:   "enforce",
+		},
+		{
+			name:   "other organization",
+			action: config.Enforcement{Organizations: map[string]string{"mo-octocat": "audit"}},
+			org:    "other",
+			want:   "enforce",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveEnforcementMode(tt.action, tt.global, tt.org); got != tt.want {
+				t.Errorf("resolveEnforcementMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateEnforcement(t *testing.T) {
+	tests := []struct {
+		name    string
+		e       config.Enforcement
+		wantErr bool
+	}{
+		{name: "empty", e: config.Enforcement{}},
+		{name: "valid", e: config.Enforcement{Mode: "audit", Organizations: map[string]string{"mo-octocat": "enforce"}}},
+		{name: "invalid mode", e: config.Enforcement{Mode: "dry-run"}, wantErr: true},
+		{name: "invalid organization mode", e: config.Enforcement{Organizations: map[string]string{"mo-octocat": "off"}}, wantErr: true},
+		{name: "repository scope", e: config.Enforcement{Scope: "repository"}},
+		{name: "confirmed organization scope", e: config.Enforcement{Scope: "organization", ConfirmOrganization: true}},
+		{name: "unconfirmed organization scope", e: config.Enforcement{Scope: "organization"}, wantErr: true},
+		{name: "invalid scope", e: config.Enforcement{Scope: "enterprise"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateEnforcement(tt.e); (err != nil) != tt.wantErr {
+				t.Errorf("validateEnforcement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveEnforcementScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		action config.Enforcement
+		global config.Enforcement
+		want   string
+	}{
+		{name: "default", want: "workflow"},
+		{name: "global", global: config.Enforcement{Scope: "repository"}, want: "repository"},
+		{name: "action overrides global", action: config.Enforcement{Scope: "workflow"}, global: config.Enforcement{Scope: "repository"}, want: "workflow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveEnforcementScope(tt.action, tt.global); got != tt.want {
+				t.Errorf("resolveEnforcementScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+var defaultEscalationStages = []config.EscalationStage{
+	{Action: utils.EscalationNotify},
+	{Action: utils.EscalationIssue, AfterDays: 3, AfterRuns: 5},
+	{Action: utils.EscalationDisable, AfterDays: 7, AfterRuns: 10},
+}
+
+// violation is the persisted escalation state of a repository that failed validation
+type violation struct {
+	FirstSeen time.Time             `json:"first_seen"`
+	LastSeen  time.Time             `json:"last_seen"`
+	Runs      int                   `json:"runs"`
+	RunIDs    []int64               `json:"run_ids,omitempty"`
+	Stage     int                   `json:"stage"`
+	EventType string                `json:"event_type"`
+	Workflows map[int64]string      `json:"workflows"`
+	Issue     int                   `json:"issue,omitempty"`
+	Last      *WorkflowActionParams `json:"last"`
+}
+
+// addRun counts the run unless it has been counted already, as every run is delivered several times. Only the
+// latest MaxViolationRunIDs runs are remembered.
+func (v *violation) addRun(runID int64) bool {
+	if runID != 0 {
+		for _, id := range v.RunIDs {
+			if id == runID {
+				return false
+			}
+		}
+		v.RunIDs = append(v.RunIDs, runID)
+		if len(v.RunIDs) > utils.MaxViolationRunIDs {
+			v.RunIDs = v.RunIDs[len(v.RunIDs)-utils.MaxViolationRunIDs:]
+		}
+	}
+	v.Runs++
+	return true
+}
+
+func newEscalation(escalation config.EscalationConfig) (config.EscalationConfig, error) {
+	if !escalation.Enabled {
+		return escalation, nil
+	}
+	if len(escalation.Stages) == 0 {
+		escalation.Stages = defaultEscalationStages
+	}
+	if escalation.Interval <= 0 {
+		escalation.Interval = utils.DefaultEscalationInterval
+	}
+
+	for i, stage := range escalation.Stages {
+		switch stage.Action {
+		case utils.EscalationNotify, utils.EscalationIssue, utils.EscalationDisable:
+		default:
+			return escalation, fmt.Errorf(utils.ErrInvalidEscalationStage, i, "unknown action "+stage.Action)
+		}
+		if i > 0 && stage.AfterDays <= 0 && stage.AfterRuns <= 0 {
+			return escalation, fmt.Errorf(utils.ErrInvalidEscalationStage, i, "after_days or after_runs is required")
+		}
+	}
+
+	return escalation, nil
+}
+
+// targetStage returns the index of the highest stage the violation has reached, the first stage is
+// reached immediately and every later stage after its days or runs threshold, whichever comes first.
+func targetStage(stages []config.EscalationStage, v *violation, now time.Time) int {
+	target := 0
+	for i := 1; i < len(stages); i++ {
+		stage := stages[i]
+		byDays := stage.AfterDays > 0 && now.Sub(v.FirstSeen) >= time.Duration(stage.AfterDays)*24*time.Hour
+		byRuns := stage.AfterRuns > 0 && v.Runs >= stage.AfterRuns
+		if !byDays && !byRuns {
+			break
+		}
+		target = i
+	}
+	return target
+}
+
+// violationKey scopes violations to the action so that several actions can share a state store
+func (w *WorkflowAction) violationKey(org, repo string) string {
+	return fmt.Sprintf("%s/%s:%s/%s", w.organization, w.repository, org, repo)
+}
+
+// escalate records the violation and runs every stage that has been reached since the last event
+func (w *WorkflowAction) escalate(ctx context.Context, p *WorkflowActionParams, eventType string, result *ValidationResult) error {
+	key := w.violationKey(p.Organization, p.Repository)
+	now := time.Now().UTC()
+
+	unlock := w.violationMu.Lock(key)
+	defer unlock()
+
+	v := &violation{}
+	found, err := w.state.Get(utils.StateBucketViolations, key, v)
+	if err != nil {
+		return err
+	}
+	if !found {
+		v = &violation{FirstSeen: now, Stage: -1, Workflows: make(map[int64]string)}
+	}
+	v.LastSeen = now
+	v.addRun(p.RunID)
+	v.EventType = eventType
+	v.Workflows[p.WorkflowID] = p.WorkflowName
+	v.Last = p
+
+	disabled := w.disableStageReached(v.Stage)
+	if err := w.advance(ctx, key, v, result, now); err != nil {
+		return err
+	}
+	if !disabled {
+		if w.disableStageReached(v.Stage) {
+			// the workflows have just been disabled by the stage
+			w.cancelRun(ctx, p, result)
+		}
+		return nil
+	}
+
+	// the violation has been disabled before, the workflow runs again and is disabled once more
+	if !w.breaker.Allow(ctx, p.Organization) {
+		w.logger.Warnw("circuit breaker open, workflow not disabled", "organization", p.Organization, "repository", p.Repository, "workflow_id", p.WorkflowID)
+		w.recordAudit(p, utils.AuditActionWouldDisable, result)
+		return nil
+	}
+	if err := w.enforce(ctx, p, map[int64]string{p.WorkflowID: p.WorkflowName}, result, v.Issue); err != nil {
+		return err
+	}
+	w.cancelRun(ctx, p, result)
+	return nil
+}
+
+// disableStageReached reports whether a disable stage is among the stages up to stage
+func (w *WorkflowAction) disableStageReached(stage int) bool {
+	for i := 0; i <= stage && i < len(w.escalation.Stages); i++ {
+		if w.escalation.Stages[i].Action == utils.EscalationDisable {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *WorkflowAction) advance(ctx context.Context, key string, v *violation, result *ValidationResult, now time.Time) error {
+	target := targetStage(w.escalation.Stages, v, now)
+	for v.Stage < target {
+		stage := w.escalation.Stages[v.Stage+1]
+		if err := w.runStage(ctx, v, stage, result); err != nil {
+			// persist the progress made so far, the failed stage is retried on the next run
+			if putErr := w.state.Put(utils.StateBucketViolations, key, v); putErr != nil {
+				w.logger.Errorw("error persisting violation", "key", key, "error", putErr)
+			}
+			return err
+		}
+		v.Stage++
+	}
+
+	return w.state.Put(utils.StateBucketViolations, key, v)
+}
+
+func (w *WorkflowAction) runStage(ctx context.Context, v *violation, stage config.EscalationStage, result *ValidationResult) error {
+	p := v.Last
+	w.logger.Infow("escalating violation", "organization", p.Organization, "repository", p.Repository, "stage", stage.Action, "runs", v.Runs)
+
+	switch stage.Action {
+	case utils.EscalationNotify:
+		w.recordAudit(p, utils.AuditActionNotified, result)
+		return nil
+	case utils.EscalationIssue:
+		return w.openViolationIssue(ctx, v, result)
+	case utils.EscalationDisable:
+		if !w.breaker.Allow(ctx, p.Organization) {
+			// the stage is retried once the breaker has been reset
+			return fmt.Errorf(utils.ErrCircuitBreakerOpen, p.Organization)
+		}
+		if err := w.enforce(ctx, p, v.Workflows, result, v.Issue); err != nil {
+			return err
+		}
+		if w.hasIssueStage() {
+			return nil
+		}
+		if err := w.openViolationIssue(ctx, v, result); err != nil {
+			return err
+		}
+		w.attachIssue(p, v.Workflows, v.Issue)
+		return nil
+	}
+
+	return nil
+}
+
+func (w *WorkflowAction) hasIssueStage() bool {
+	for _, stage := range w.escalation.Stages {
+		if stage.Action == utils.EscalationIssue {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *WorkflowAction) openViolationIssue(ctx context.Context, v *violation, result *ValidationResult) error {
+	p := v.Last
+	issue, err := w.reportWorkflowIssue(ctx, v.EventType, p, result)
+	if err != nil {
+		return err
+	}
+	v.Issue = issue.GetNumber()
+	w.recordAudit(p, utils.AuditActionIssueCreated, result)
+	return nil
+}
+
+// clearViolation resets the escalation of a repository that validates again
+func (w *WorkflowAction) clearViolation(p *WorkflowActionParams) {
+	if !w.escalation.Enabled {
+		return
+	}
+	if err := w.state.Delete(utils.StateBucketViolations, w.violationKey(p.Organization, p.Repository)); err != nil {
+		w.logger.Errorw("error clearing violation", "organization", p.Organization, "repository", p.Repository, "error", err)
+	}
+}
+
+// advanceEscalations moves pending violations to the stage they reached by time. Repositories are
+// validated again first so that remediated repositories are not escalated any further.
+func (w *WorkflowAction) advanceEscalations(ctx context.Context) error {
+	now := time.Now().UTC()
+	prefix := fmt.Sprintf("%s/%s:", w.organization, w.repository)
+	for _, key := range w.state.Keys(utils.StateBucketViolations) {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		v := &violation{}
+		if _, err := w.state.Get(utils.StateBucketViolations, key, v); err != nil {
+			return err
+		}
+		p := v.Last
+		if p == nil {
+			continue
+		}
+
+		result, err := w.repoAction().HandleRepo(ctx, &RepoActionParams{
+			ValidationOrganization: p.Organization,
+			ValidationRepository:   p.Repository,
+			Event:                  p,
+		})
+		if err == nil {
+			w.logger.Infow("violation remediated", "organization", p.Organization, "repository", p.Repository)
+			w.clearViolation(p)
+			continue
+		}
+
+		if w.enforcementMode(p.Organization) == utils.EnforcementModeAudit || w.exemptionFor(ctx, p) != nil {
+			continue
+		}
+		if err := w.advanceViolation(ctx, key, result, now); err != nil {
+			w.logger.Errorw("error advancing violation", "key", key, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// advanceViolation reloads the violation under its lock, as runs may have been recorded while the repository was
+// validated, and advances it
+func (w *WorkflowAction) advanceViolation(ctx context.Context, key string, result *ValidationResult, now time.Time) error {
+	unlock := w.violationMu.Lock(key)
+	defer unlock()
+
+	v := &violation{}
+	found, err := w.state.Get(utils.StateBucketViolations, key, v)
+	if err != nil || !found || v.Last == nil {
+		return err
+	}
+	return w.advance(ctx, key, v, result, now)
+}
+
+func (w *WorkflowAction) escalationJob() scheduler.Job {
+	return scheduler.Job{
+		Name:     fmt.Sprintf("escalation-%s/%s", w.organization, w.repository),
+		Interval: w.escalation.Interval,
+		Run:      w.advanceEscalations,
+	}
+}
@@ -0,0 +1,227 @@
+package actions
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestNewEscalation(t *testing.T) {
+	tests := []struct {
+		name       string
+		escalation config.EscalationConfig
+		wantStages int
+		wantErr    bool
+	}{
+		{name: "disabled", escalation: config.EscalationConfig{}, wantStages: 0},
+		{name: "default stages", escalation: config.EscalationConfig{Enabled: true}, wantStages: 3},
+		{
+			name: "custom stages",
+			escalation: config.EscalationConfig{Enabled: true, Stages: []config.EscalationStage{
+				{Action: utils.EscalationIssue},
+				{Action: utils.EscalationDisable, AfterRuns: 3},
+			}},
+			wantStages: 2,
+		},
+		{
+			name: "unknown action",
+			escalation: config.EscalationConfig{Enabled: true, Stages: []config.EscalationStage{
+				{Action: "archive"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "missing threshold",
+			escalation: config.EscalationConfig{Enabled: true, Stages: []config.EscalationStage{
+				{Action: utils.EscalationNotify},
+				{Action: utils.EscalationDisable},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newEscalation(tt.escalation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newEscalation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got.Stages) != tt.wantStages {
+				t.Errorf("newEscalation() stages = %d, want %d", len(got.Stages), tt.wantStages)
+			}
+			if got.Enabled && got.Interval != utils.DefaultEscalationInterval {
+				t.Errorf("newEscalation() interval = %v", got.Interval)
+			}
+		})
+	}
+}
+
+func TestTargetStage(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		firstSeen time.Time
+		runs      int
+		want      int
+	}{
+		{name: "first violation", firstSeen: now, runs: 1, want: 0},
+		{name: "issue by runs", firstSeen: now, runs: 5, want: 1},
+		{name: "issue by days", firstSeen: now.AddDate(0, 0, -3), runs: 1, want: 1},
+		{name: "disable by runs", firstSeen: now, runs: 10, want: 2},
+		{name: "disable by days", firstSeen: now.AddDate(0, 0, -7), runs: 2, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &violation{FirstSeen: tt.firstSeen, Runs: tt.runs}
+			if got := targetStage(defaultEscalationStages, v, now); got != tt.want {
+				t.Errorf("targetStage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func escalationConfig(stages ...map[string]any) map[string]any {
+	var raw []any
+	for _, stage := range stages {
+		raw = append(raw, stage)
+	}
+	return map[string]any{"escalation": map[string]any{"enabled": true, "stages": raw}}
+}
+
+func TestWorkflowAction_escalate_countsRuns(t *testing.T) {
+	w := newTestWorkflowAction(t, escalationConfig(
+		map[string]any{"action": utils.EscalationNotify},
+		map[string]any{"action": utils.EscalationIssue, "after_runs": 3},
+	), nil)
+	ctx := context.Background()
+
+	for runID := int64(1); runID <= 2; runID++ {
+		for _, p := range deliveries(42, runID) {
+			if err := w.handleWorkflowEvent(ctx, p, "run"); err != nil {
+				t.Fatalf("handleWorkflowEvent() error = %v", err)
+			}
+		}
+	}
+	v := &violation{}
+	if _, err := w.state.Get(utils.StateBucketViolations, w.violationKey(testOrganization, "flutter-template"), v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Runs != 2 || v.Stage != 0 {
+		t.Errorf("violation runs = %d, stage = %d, want 2 runs at stage 0", v.Runs, v.Stage)
+	}
+	if got := len(w.fake.called(http.MethodPost, "/repos/mo-octocat/actions-registry/issues")); got != 0 {
+		t.Errorf("issues created = %d, want 0", got)
+	}
+
+	for _, p := range deliveries(42, 3) {
+		if err := w.handleWorkflowEvent(ctx, p, "run"); err != nil {
+			t.Fatalf("handleWorkflowEvent() error = %v", err)
+		}
+	}
+	if got := len(w.fake.called(http.MethodPost, "/repos/mo-octocat/actions-registry/issues")); got != 1 {
+		t.Errorf("issues created = %d, want 1", got)
+	}
+	if got := w.actions(t)[utils.AuditActionNotified]; got != 1 {
+		t.Errorf("notified entries = %d, want 1", got)
+	}
+}
+
+func TestWorkflowAction_escalate_concurrentRuns(t *testing.T) {
+	w := newTestWorkflowAction(t, escalationConfig(map[string]any{"action": utils.EscalationNotify}), nil)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for runID := int64(1); runID <= 20; runID++ {
+		for _, p := range deliveries(42, runID) {
+			wg.Add(1)
+			go func(p *WorkflowActionParams) {
+				defer wg.Done()
+				// every delivery is escalated, distinct runs are counted by the violation itself
+				if err := w.escalate(ctx, p, "run", &ValidationResult{}); err != nil {
+					t.Errorf("escalate() error = %v", err)
+				}
+			}(p)
+		}
+	}
+	wg.Wait()
+
+	v := &violation{}
+	if _, err := w.state.Get(utils.StateBucketViolations, w.violationKey(testOrganization, "flutter-template"), v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Runs != 20 {
+		t.Errorf("violation runs = %d, want 20", v.Runs)
+	}
+}
+
+func TestWorkflowAction_handleWorkflowEvent_countsRunOnce(t *testing.T) {
+	w := newTestWorkflowAction(t, nil, &config.CircuitBreaker{OrganizationThreshold: 2})
+	w.fake.reply(http.MethodPut, "/repos/mo-octocat/flutter-template/actions/workflows/42/disable", http.StatusNoContent, nil)
+	ctx := context.Background()
+
+	for _, p := range deliveries(42, 1) {
+		if err := w.handleWorkflowEvent(ctx, p, "run"); err != nil {
+			t.Fatalf("handleWorkflowEvent() error = %v", err)
+		}
+	}
+
+	if got := len(w.fake.called(http.MethodPut, "/repos/mo-octocat/flutter-template/actions/workflows/42/disable")); got != 1 {
+		t.Errorf("workflow disabled %d times, want 1", got)
+	}
+	if got := len(w.fake.called(http.MethodPost, "/repos/mo-octocat/actions-registry/issues")); got != 1 {
+		t.Errorf("issues created = %d, want 1", got)
+	}
+	if w.breaker.Tripped(testOrganization) {
+		t.Error("circuit breaker tripped by the deliveries of a single run")
+	}
+}
+
+func TestWorkflowAction_handleWorkflowEvent_breakerDefersDisable(t *testing.T) {
+	w := newTestWorkflowAction(t, escalationConfig(map[string]any{"action": utils.EscalationDisable}), &config.CircuitBreaker{OrganizationThreshold: 1})
+	disable := "/repos/mo-octocat/flutter-template/actions/workflows/42/disable"
+	w.fake.reply(http.MethodPut, disable, http.StatusNoContent, nil)
+	ctx := context.Background()
+
+	// another workflow of the organization has been disabled within the window
+	w.breaker.Allow(ctx, testOrganization)
+
+	for i, p := range deliveries(42, 1) {
+		err := w.handleWorkflowEvent(ctx, p, "run")
+		if i == 0 && err == nil {
+			t.Fatal("handleWorkflowEvent() error = nil, want the circuit breaker to defer the disable stage")
+		}
+		if i > 0 && err != nil {
+			t.Fatalf("handleWorkflowEvent() error = %v", err)
+		}
+	}
+	if got := len(w.fake.called(http.MethodPut, disable)); got != 0 {
+		t.Fatalf("workflow disabled %d times while the circuit breaker is open, want 0", got)
+	}
+	v := &violation{}
+	if _, err := w.state.Get(utils.StateBucketViolations, w.violationKey(testOrganization, "flutter-template"), v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Stage != -1 {
+		t.Errorf("violation stage = %d, want -1 until the disable stage has run", v.Stage)
+	}
+	if got := w.actions(t)[utils.AuditActionWouldDisable]; got == 0 {
+		t.Error("deliveries while the circuit breaker is open were not audited as would-disable")
+	}
+
+	if _, err := w.breaker.Reset(testOrganization, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.advanceEscalations(ctx); err != nil {
+		t.Fatalf("advanceEscalations() error = %v", err)
+	}
+	if got := len(w.fake.called(http.MethodPut, disable)); got != 1 {
+		t.Errorf("workflow disabled %d times after the reset, want 1", got)
+	}
+}
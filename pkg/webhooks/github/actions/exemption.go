@@ -0,0 +1,269 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// exemptionRegistry holds the exemptions of the config file and the ones loaded from the central repository
+type exemptionRegistry struct {
+	config config.ExemptionsConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	fetched time.Time
+	repo    []config.Exemption
+}
+
+// exemptionReminder is the persisted record of an expired exemption an issue has been opened for
+type exemptionReminder struct {
+	Scope      string    `json:"scope"`
+	Expires    string    `json:"expires"`
+	NotifiedAt time.Time `json:"notified_at"`
+}
+
+func newExemptionRegistry(c config.ExemptionsConfig) (*exemptionRegistry, error) {
+	for _, e := range c.Entries {
+		if err := validateExemption(e); err != nil {
+			return nil, err
+		}
+	}
+	if c.ReminderInterval <= 0 {
+		c.ReminderInterval = utils.DefaultExemptionInterval
+	}
+	if c.ReloadInterval <= 0 {
+		c.ReloadInterval = utils.DefaultExemptionReloadInterval
+	}
+	return &exemptionRegistry{config: c, now: time.Now}, nil
+}
+
+// stale reports whether the exemptions of the central repository are due to be read again
+func (r *exemptionRegistry) stale() bool {
+	if r.config.Path == "" {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fetched.IsZero() || r.now().Sub(r.fetched) >= r.config.ReloadInterval
+}
+
+// touch delays the next reload, the loaded exemptions are kept
+func (r *exemptionRegistry) touch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetched = r.now()
+}
+
+func (r *exemptionRegistry) enabled() bool {
+	return len(r.config.Entries) > 0 || r.config.Path != ""
+}
+
+func (r *exemptionRegistry) entries() []config.Exemption {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append(append([]config.Exemption{}, r.config.Entries...), r.repo...)
+}
+
+func validateExemption(e config.Exemption) error {
+	scope := exemptionScope(e)
+	if e.Organization == "" {
+		return fmt.Errorf(utils.ErrInvalidExemption, scope, "organization is required")
+	}
+	if e.Reason == "" {
+		return fmt.Errorf(utils.ErrInvalidExemption, scope, "reason is required")
+	}
+	if e.Approver == "" {
+		return fmt.Errorf(utils.ErrInvalidExemption, scope, "approver is required")
+	}
+	if _, err := time.Parse(utils.RegistrationExpiryLayout, e.Expires); err != nil {
+		return fmt.Errorf(utils.ErrInvalidExemption, scope, "expires must be formatted as YYYY-MM-DD")
+	}
+	return nil
+}
+
+// exemptionScope describes what an exemption applies to, e.g. mo-octocat/api workflow build
+func exemptionScope(e config.Exemption) string {
+	scope := e.Organization
+	if e.Repository != "" {
+		scope += "/" + e.Repository
+	}
+	if e.Workflow != "" {
+		scope += " workflow " + e.Workflow
+	}
+	if e.Sender != "" {
+		scope += " sender " + e.Sender
+	}
+	return scope
+}
+
+// exemptionExpired reports whether now is past the expiry day of the exemption, the expiry day is inclusive
+func exemptionExpired(e config.Exemption, now time.Time) bool {
+	expires, err := time.Parse(utils.RegistrationExpiryLayout, e.Expires)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expires.AddDate(0, 0, 1))
+}
+
+func exemptionMatches(e config.Exemption, p *WorkflowActionParams) bool {
+	if !strings.EqualFold(e.Organization, p.Organization) {
+		return false
+	}
+	if e.Repository != "" && !strings.EqualFold(e.Repository, p.Repository) {
+		return false
+	}
+	if e.Workflow != "" && e.Workflow != p.WorkflowName && e.Workflow != p.WorkflowPath {
+		return false
+	}
+	if e.Sender != "" && !strings.EqualFold(e.Sender, p.Sender) {
+		return false
+	}
+	return true
+}
+
+// matchExemption returns the first active exemption that applies to the event
+func matchExemption(exemptions []config.Exemption, p *WorkflowActionParams, now time.Time) *config.Exemption {
+	for i := range exemptions {
+		if exemptionMatches(exemptions[i], p) && !exemptionExpired(exemptions[i], now) {
+			return &exemptions[i]
+		}
+	}
+	return nil
+}
+
+// exemptionFor returns the active exemption of the event, the repository exemptions are read again once they are
+// older than the reload interval
+func (w *WorkflowAction) exemptionFor(ctx context.Context, p *WorkflowActionParams) *config.Exemption {
+	if !w.exemptions.enabled() {
+		return nil
+	}
+
+	if w.exemptions.stale() {
+		if err := w.loadExemptions(ctx); err != nil {
+			// the previous exemptions stay in use until the next reload
+			w.logger.Errorw("error loading exemptions", "path", w.exemptions.config.Path, "error", err)
+			w.exemptions.touch()
+		}
+	}
+
+	return matchExemption(w.exemptions.entries(), p, w.exemptions.now())
+}
+
+// loadExemptions reads the exemptions file of the central repository, invalid entries are skipped
+func (w *WorkflowAction) loadExemptions(ctx context.Context) error {
+	if w.exemptions.config.Path == "" {
+		return nil
+	}
+
+	content, err := w.repoAction().readFile(ctx, w.exemptions.config.Path)
+	if err != nil {
+		return err
+	}
+
+	var entries []config.Exemption
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return err
+	}
+
+	valid := make([]config.Exemption, 0, len(entries))
+	for _, e := range entries {
+		if err := validateExemption(e); err != nil {
+			w.logger.Warnw("skipping exemption", "path", w.exemptions.config.Path, "error", err)
+			continue
+		}
+		valid = append(valid, e)
+	}
+
+	w.exemptions.mu.Lock()
+	defer w.exemptions.mu.Unlock()
+	w.exemptions.repo = valid
+	w.exemptions.fetched = w.exemptions.now()
+	return nil
+}
+
+// exemptionReminderKey scopes the reminders of an exemption to the action in the same way as violations
+func (w *WorkflowAction) exemptionReminderKey(e config.Exemption) string {
+	return fmt.Sprintf("%s/%s:%s:%s", w.organization, w.repository, exemptionScope(e), e.Expires)
+}
+
+// checkExemptions reloads the exemptions and opens an issue for every exemption that has expired. Every expiry is
+// reminded of once, the reminders are kept in the state store so that closed issues are not opened again.
+func (w *WorkflowAction) checkExemptions(ctx context.Context) error {
+	if err := w.loadExemptions(ctx); err != nil {
+		return err
+	}
+
+	now := w.exemptions.now()
+	expired := make(map[string]config.Exemption)
+	for _, e := range w.exemptions.entries() {
+		if exemptionExpired(e, now) {
+			expired[w.exemptionReminderKey(e)] = e
+		}
+	}
+
+	// reminders of exemptions that have been removed or renewed are dropped
+	prefix := fmt.Sprintf("%s/%s:", w.organization, w.repository)
+	for _, key := range w.state.Keys(utils.StateBucketExemptionReminders) {
+		if _, ok := expired[key]; !ok && strings.HasPrefix(key, prefix) {
+			if err := w.state.Delete(utils.StateBucketExemptionReminders, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	var pending []config.Exemption
+	for key, e := range expired {
+		found, err := w.state.Get(utils.StateBucketExemptionReminders, key, &exemptionReminder{})
+		if err != nil {
+			return err
+		}
+		if !found {
+			pending = append(pending, e)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	open, err := w.openIssueTitles(ctx, utils.LabelExemptionExpired)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range pending {
+		scope := exemptionScope(e)
+		title := fmt.Sprintf(utils.ExemptionExpiredTitle, scope, e.Expires)
+		if !open[title] {
+			message := fmt.Sprintf(utils.ExemptionExpiredMessage, scope, e.Expires, e.Approver, e.Reason)
+			if _, err := w.createWorkflowIssue(ctx, title, message, *w.assignees, []string{utils.LabelExemptionExpired}); err != nil {
+				return err
+			}
+			w.logger.Infow("exemption expired", "scope", scope, "expires", e.Expires)
+		}
+
+		reminder := &exemptionReminder{Scope: scope, Expires: e.Expires, NotifiedAt: now.UTC()}
+		if err := w.state.Put(utils.StateBucketExemptionReminders, w.exemptionReminderKey(e), reminder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *WorkflowAction) exemptionJob() scheduler.Job {
+	return scheduler.Job{
+		Name:     fmt.Sprintf("exemptions-%s/%s", w.organization, w.repository),
+		Interval: w.exemptions.config.ReminderInterval,
+		Run:      w.checkExemptions,
+	}
+}
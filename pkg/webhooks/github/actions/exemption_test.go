@@ -0,0 +1,143 @@
+package actions
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestValidateExemption(t *testing.T) {
+	tests := []struct {
+		name      string
+		exemption config.Exemption
+		wantErr   bool
+	}{
+		{name: "valid", exemption: config.Exemption{Organization: "mo-octocat", Reason: "migration", Approver: "mouismail", Expires: "2023-07-01"}},
+		{name: "missing organization", exemption: config.Exemption{Reason: "migration", Approver: "mouismail", Expires: "2023-07-01"}, wantErr: true},
+		{name: "missing reason", exemption: config.Exemption{Organization: "mo-octocat", Approver: "mouismail", Expires: "2023-07-01"}, wantErr: true},
+		{name: "missing approver", exemption: config.Exemption{Organization: "mo-octocat", Reason: "migration", Expires: "2023-07-01"}, wantErr: true},
+		{name: "invalid expiry", exemption: config.Exemption{Organization: "mo-octocat", Reason: "migration", Approver: "mouismail", Expires: "07/01/2023"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateExemption(tt.exemption); (err != nil) != tt.wantErr {
+				t.Errorf("validateExemption() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchExemption(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	exemptions := []config.Exemption{
+		{Organization: "mo-octocat", Repository: "api", Expires: "2023-06-30"},
+		{Organization: "mo-octocat", Repository: "web", Workflow: ".github/workflows/deploy.yml", Expires: "2023-06-30"},
+		{Organization: "mo-octocat", Sender: "renovate[bot]", Expires: "2023-06-30"},
+		{Organization: "mo-octocat", Repository: "legacy", Expires: "2023-06-14"},
+		{Organization: "other", Expires: "2023-06-15"},
+	}
+
+	tests := []struct {
+		name string
+		p    *WorkflowActionParams
+		want string
+	}{
+		{name: "repository", p: &WorkflowActionParams{Organization: "mo-octocat", Repository: "api", WorkflowName: "build"}, want: "mo-octocat/api"},
+		{name: "workflow path", p: &WorkflowActionParams{Organization: "mo-octocat", Repository: "web", WorkflowPath: ".github/workflows/deploy.yml"}, want: "mo-octocat/web workflow .github/workflows/deploy.yml"},
+		{name: "other workflow", p: &WorkflowActionParams{Organization: "mo-octocat", Repository: "web", WorkflowPath: ".github/workflows/ci.yml"}},
+		{name: "sender", p: &WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template", Sender: "renovate[bot]"}, want: "mo-octocat sender renovate[bot]"},
+		{name: "expired", p: &WorkflowActionParams{Organization: "mo-octocat", Repository: "legacy"}},
+		{name: "expiry day is inclusive", p: &WorkflowActionParams{Organization: "other", Repository: "web"}, want: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if e := matchExemption(exemptions, tt.p, now); e != nil {
+				got = exemptionScope(*e)
+			}
+			if got != tt.want {
+				t.Errorf("matchExemption() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_checkExemptions(t *testing.T) {
+	w := newTestWorkflowAction(t, map[string]any{"exemptions": map[string]any{"path": "exemptions/exemptions.yml"}}, nil)
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	w.exemptions.now = func() time.Time { return now }
+	serve := func(expires string) {
+		w.fake.files(testOrganization, testRepository, "exemptions", map[string]string{"exemptions.yml": `
+- organization: mo-octocat
+  repository: flutter-template
+  reason: migration
+  approver: mouismail
+  expires: ` + expires + `
+- organization: mo-octocat
+  repository: api
+  reason: migration
+  approver: mouismail
+  expires: "2023-07-01"
+`})
+	}
+	issuesCreated := func() int {
+		return len(w.fake.called(http.MethodPost, "/repos/mo-octocat/actions-registry/issues"))
+	}
+	ctx := context.Background()
+
+	serve(`"2023-06-01"`)
+	// the fake has no open issues, as if the reminder had been closed after every run
+	for i := 0; i < 3; i++ {
+		if err := w.checkExemptions(ctx); err != nil {
+			t.Fatalf("checkExemptions() error = %v", err)
+		}
+	}
+	if got := issuesCreated(); got != 1 {
+		t.Errorf("issues created = %d, want 1", got)
+	}
+
+	// a renewed exemption that expires again is reminded of again
+	serve(`"2023-06-10"`)
+	if err := w.checkExemptions(ctx); err != nil {
+		t.Fatalf("checkExemptions() error = %v", err)
+	}
+	if got := issuesCreated(); got != 2 {
+		t.Errorf("issues created = %d, want 2", got)
+	}
+	if got := len(w.state.Keys(utils.StateBucketExemptionReminders)); got != 1 {
+		t.Errorf("reminders = %d, want 1", got)
+	}
+}
+
+func TestWorkflowAction_exemptionFor_reload(t *testing.T) {
+	w := newTestWorkflowAction(t, map[string]any{"exemptions": map[string]any{"path": "exemptions/exemptions.yml", "reload_interval": "10m"}}, nil)
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	w.exemptions.now = func() time.Time { return now }
+	p := &WorkflowActionParams{Organization: testOrganization, Repository: "flutter-template", WorkflowName: "build"}
+	ctx := context.Background()
+
+	w.fake.files(testOrganization, testRepository, "exemptions", map[string]string{"exemptions.yml": "[]"})
+	if got := w.exemptionFor(ctx, p); got != nil {
+		t.Fatalf("exemptionFor() = %v, want nil", got)
+	}
+
+	w.fake.files(testOrganization, testRepository, "exemptions", map[string]string{"exemptions.yml": `
+- organization: mo-octocat
+  repository: flutter-template
+  reason: migration
+  approver: mouismail
+  expires: "2023-07-01"
+`})
+	now = now.Add(5 * time.Minute)
+	if got := w.exemptionFor(ctx, p); got != nil {
+		t.Errorf("exemptionFor() = %v before the reload interval, want nil", got)
+	}
+	now = now.Add(5 * time.Minute)
+	if got := w.exemptionFor(ctx, p); got == nil || got.Reason != "migration" {
+		t.Errorf("exemptionFor() = %v after the reload interval, want the added exemption", got)
+	}
+}
@@ -0,0 +1,310 @@
+package actions
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/breaker"
+	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/state"
+)
+
+const (
+	testOrganization = "mo-octocat"
+	testRepository   = "actions-registry"
+)
+
+// testRegistration registers mo-octocat/flutter-template, serve it with fakeGitHub.files
+const testRegistration = `
+url: https://octodemo.com/mo-octocat
+contactEmail: octocat
+useCase: mo-octocat
+repos:
+  - https://octodemo.com/mo-octocat/flutter-template
+`
+
+// fakeRequest is a request received by the fake GitHub API
+type fakeRequest struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+// fakeGitHub is a local GitHub Enterprise API that answers the registered routes and records every request.
+// Routes are given without the /api/v3 prefix (/api for GraphQL), unknown routes answer with 404.
+type fakeGitHub struct {
+	*httptest.Server
+	keyPath string
+
+	mu       sync.Mutex
+	routes   map[string]http.HandlerFunc
+	requests []fakeRequest
+}
+
+var (
+	fakeAppKeyOnce sync.Once
+	fakeAppKey     []byte
+)
+
+// fakeAppKeyPEM returns the private key of the fake GitHub App, generated once per test binary
+func fakeAppKeyPEM(t *testing.T) []byte {
+	t.Helper()
+	fakeAppKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeAppKey = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	})
+	return fakeAppKey
+}
+
+// fakeCloudTransport sends the requests of the app client, which always talks to api.github.com, to the fake API
+type fakeCloudTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t *fakeCloudTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Host == "api.github.com" {
+		r = r.Clone(r.Context())
+		r.URL.Scheme = t.target.Scheme
+		r.URL.Host = t.target.Host
+		r.Host = ""
+	}
+	return t.base.RoundTrip(r)
+}
+
+// fakeInstallation answers the installation lookups and token requests of the app client
+func fakeInstallation(w http.ResponseWriter, r *http.Request, path string) bool {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/installation") && (parts[0] == "repos" || parts[0] == "orgs"):
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	case r.Method == http.MethodPost && path == "/app/installations/1/access_tokens":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token": "token"}`))
+	default:
+		return false
+	}
+	return true
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	f := &fakeGitHub{routes: make(map[string]http.HandlerFunc)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/v3"), "/api")
+		raw, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		var body map[string]any
+		_ = json.Unmarshal(raw, &body)
+
+		f.mu.Lock()
+		f.requests = append(f.requests, fakeRequest{Method: r.Method, Path: path, Body: body})
+		route, ok := f.routes[r.Method+" "+path]
+		f.mu.Unlock()
+
+		if !ok && fakeInstallation(w, r, path) {
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		route(w, r)
+	}))
+	t.Cleanup(f.Close)
+
+	f.keyPath = filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(f.keyPath, fakeAppKeyPEM(t), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	target, _ := url.Parse(f.URL)
+	base := http.DefaultTransport
+	http.DefaultTransport = &fakeCloudTransport{target: target, base: base}
+	t.Cleanup(func() { http.DefaultTransport = base })
+	return f
+}
+
+func (f *fakeGitHub) handle(method, path string, route http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[method+" "+path] = route
+}
+
+// reply answers the route with the status and the JSON encoded body, a nil body is sent empty
+func (f *fakeGitHub) reply(method, path string, status int, body any) {
+	f.handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if body != nil {
+			_ = json.NewEncoder(w).Encode(body)
+		}
+	})
+}
+
+// files serves the files of a folder of the repository at the main branch, as RepoAction reads registrations
+func (f *fakeGitHub) files(owner, repo, folder string, files map[string]string) {
+	var listing []map[string]any
+	for name, content := range files {
+		content := content
+		download := "/raw/" + owner + "/" + repo + "/" + folder + "/" + name
+		listing = append(listing, map[string]any{
+			"type":         "file",
+			"name":         name,
+			"path":         folder + "/" + name,
+			"download_url": f.URL + download,
+		})
+		f.handle(http.MethodGet, download, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(content))
+		})
+	}
+	if listing == nil {
+		listing = []map[string]any{}
+	}
+	f.reply(http.MethodGet, "/repos/"+owner+"/"+repo+"/contents/"+folder, http.StatusOK, listing)
+}
+
+// called returns the requests received for the route
+func (f *fakeGitHub) called(method, path string) []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var requests []fakeRequest
+	for _, r := range f.requests {
+		if r.Method == method && r.Path == path {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// client returns an app client of the repository that talks to the fake API, installations are looked up there
+func (f *fakeGitHub) client(t *testing.T, owner, repo string) *clients.Github {
+	t.Helper()
+	serverInfo := &config.ServerInfo{BaseURL: f.URL + "/", UploadURL: f.URL + "/", EnterpriseURL: "https://octodemo.com"}
+	client, err := clients.NewGithub(zap.NewNop().Sugar(), owner, repo, serverInfo, &config.GithubClient{PrivateKeyCertPath: f.keyPath, AppID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// api returns the API of the fake for code that only needs a go-github client
+func (f *fakeGitHub) api() githubAPI {
+	return fakeAPI{url: f.URL + "/"}
+}
+
+type fakeAPI struct {
+	url string
+}
+
+func (a fakeAPI) GetV3Client() *github.Client {
+	client, _ := github.NewEnterpriseClient(a.url, a.url, nil)
+	return client
+}
+
+// issues serves an issue tracker without open issues or labels in which created issues get consecutive numbers
+func (f *fakeGitHub) issues(owner, repo string) {
+	f.reply(http.MethodGet, "/repos/"+owner+"/"+repo+"/issues", http.StatusOK, []any{})
+	f.reply(http.MethodGet, "/repos/"+owner+"/"+repo+"/labels", http.StatusOK, []any{})
+	f.reply(http.MethodPost, "/repos/"+owner+"/"+repo+"/labels", http.StatusCreated, map[string]any{})
+	number := 0
+	f.handle(http.MethodPost, "/repos/"+owner+"/"+repo+"/issues", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		number++
+		n := number
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"number": %d}`, n)
+	})
+}
+
+// testWorkflowAction is a workflow action of the fake registry repository. The registry serves no registration,
+// so every workflow fails validation.
+type testWorkflowAction struct {
+	*WorkflowAction
+	fake  *fakeGitHub
+	audit *audit.Recorder
+}
+
+func newTestWorkflowAction(t *testing.T, rawConfig map[string]any, breakerConfig *config.CircuitBreaker) *testWorkflowAction {
+	t.Helper()
+	logger := zap.NewNop().Sugar()
+	fake := newFakeGitHub(t)
+	fake.files(testOrganization, testRepository, "registrations", nil)
+	fake.issues(testOrganization, testRepository)
+
+	recorder := audit.New(logger, &config.Audit{Path: filepath.Join(t.TempDir(), "audit.log")})
+	store, err := state.New(logger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := breaker.New(logger, recorder, store, breakerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := map[string]any{"files_path": []interface{}{"registrations"}, "issue_assignees": []interface{}{}}
+	for k, v := range rawConfig {
+		c[k] = v
+	}
+	w, err := NewWorkflowAction(logger, fake.client(t, testOrganization, testRepository), c, &Dependencies{Audit: recorder, State: store, Breaker: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testWorkflowAction{WorkflowAction: w, fake: fake, audit: recorder}
+}
+
+// deliveries returns the workflow_run events GitHub sends for one run of the workflow
+func deliveries(workflowID, runID int64) []*WorkflowActionParams {
+	var events []*WorkflowActionParams
+	for _, action := range []string{"requested", "in_progress", "completed"} {
+		events = append(events, &WorkflowActionParams{
+			WorkflowName: "build",
+			WorkflowID:   workflowID,
+			WorkflowPath: ".github/workflows/build.yml",
+			Organization: testOrganization,
+			Repository:   "flutter-template",
+			WebhookEvent: "workflow_run",
+			Action:       action,
+			Sender:       "octocat",
+			RunID:        runID,
+		})
+	}
+	return events
+}
+
+// actions counts the audit entries per action
+func (w *testWorkflowAction) actions(t *testing.T) map[string]int {
+	t.Helper()
+	entries, err := w.audit.Entries(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Action]++
+	}
+	return counts
+}
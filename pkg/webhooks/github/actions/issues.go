@@ -0,0 +1,179 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+var occurrencesLine = regexp.MustCompile(`(?m)^\*\*Occurrences:\*\* (\d+)$`)
+
+// issueMarker is the hidden marker that identifies the issue of a workflow
+func issueMarker(org, repo string, workflowID int64) string {
+	return fmt.Sprintf(utils.IssueMarker, org, repo, workflowID)
+}
+
+// occurrences returns the counter of an issue body, bodies without a counter count as one occurrence
+func occurrences(body string) int {
+	match := occurrencesLine.FindStringSubmatch(body)
+	if match == nil {
+		return 1
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// withOccurrences sets the counter of an issue body, it is appended when the body has none
+func withOccurrences(body string, n int) string {
+	line := fmt.Sprintf(utils.IssueOccurrences, n)
+	if occurrencesLine.MatchString(body) {
+		return occurrencesLine.ReplaceAllLiteralString(body, line)
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + line + "\n"
+}
+
+// reportWorkflowIssue files the issue of a workflow into the configured targets. The central issue summarizes
+// the one in the offending repository when both are targeted. It returns the central issue, nil when issues are
+// only filed into the offending repository.
+func (w *WorkflowAction) reportWorkflowIssue(ctx context.Context, eventType string, p *WorkflowActionParams, result *ValidationResult) (*github.Issue, error) {
+	title, body, err := w.renderIssue(eventType, p, result)
+	if err != nil {
+		return nil, err
+	}
+	marker := issueMarker(p.Organization, p.Repository, p.WorkflowID)
+
+	w.issueMu.Lock()
+	defer w.issueMu.Unlock()
+
+	var repoIssue *github.Issue
+	if w.filesRepository() {
+		r, err := w.issueRepository(fmt.Sprintf("%s/%s", p.Organization, p.Repository))
+		if err != nil {
+			return nil, err
+		}
+		repoIssue, err = w.fileWorkflowIssue(ctx, r, eventType, p, result, title, body, marker, w.routeAssignees(ctx, r, false, p, result))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var issue *github.Issue
+	central := w.centralRepository()
+	if w.filesCentral() {
+		r, err := w.issueRepository(central)
+		if err != nil {
+			return nil, err
+		}
+		if repoIssue != nil {
+			body += fmt.Sprintf(utils.IssueTrackedIn, p.Organization, p.Repository, repoIssue.GetNumber())
+		}
+		issue, err = w.fileWorkflowIssue(ctx, r, eventType, p, result, title, body, marker, w.routeAssignees(ctx, r, true, p, result))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.rememberIssue(p, central, issue.GetNumber(), repoIssue.GetNumber(), result)
+	return issue, nil
+}
+
+// routeAssignees picks the assignees of the workflow issue in the repository, central tells whether it is the
+// central repository or the offending one
+func (w *WorkflowAction) routeAssignees(ctx context.Context, r *issueRepository, central bool, p *WorkflowActionParams, result *ValidationResult) []string {
+	codeowners := func() []string {
+		repo := r
+		if central {
+			var err error
+			if repo, err = w.issueRepository(fmt.Sprintf("%s/%s", p.Organization, p.Repository)); err != nil {
+				w.logger.Warnw("error reading CODEOWNERS", "organization", p.Organization, "repository", p.Repository, "error", err)
+				return nil
+			}
+		}
+		owners, err := w.codeowners(ctx, repo, p.WorkflowPath)
+		if err != nil {
+			w.logger.Warnw("error reading CODEOWNERS", "repository", repo.String(), "error", err)
+		}
+		return owners
+	}
+
+	assignees, source := w.assigneeRouter.route(ctx, r, central, p, result, codeowners)
+	w.logger.Debugw("issue assignees routed", "repository", r.String(), "source", source, "assignees", assignees)
+	return assignees
+}
+
+// fileWorkflowIssue opens the issue of a workflow in the repository or, while one is still open, comments on it
+// with the new occurrence and increments its counter
+func (w *WorkflowAction) fileWorkflowIssue(ctx context.Context, r *issueRepository, eventType string, p *WorkflowActionParams, result *ValidationResult, title, body, marker string, assignees []string) (*github.Issue, error) {
+	issue, err := w.findWorkflowIssue(ctx, r, marker)
+	if err != nil {
+		return nil, err
+	}
+	if issue == nil {
+		labels, err := w.ensureLabels(ctx, r, w.issueLabels(p, result))
+		if err != nil {
+			return nil, err
+		}
+		return w.createIssue(ctx, r, title, withOccurrences(body, 1)+marker+"\n", assignees, labels)
+	}
+
+	n := occurrences(issue.GetBody()) + 1
+	issues := r.client.GetV3Client().Issues
+	if _, _, err := issues.Edit(ctx, r.owner, r.name, issue.GetNumber(), &github.IssueRequest{
+		Body: github.String(withOccurrences(issue.GetBody(), n)),
+	}); err != nil {
+		return nil, err
+	}
+
+	data := issueTemplateData(w.client.ServerInfo().EnterpriseURL, eventType, p, result)
+	comment := fmt.Sprintf(utils.IssueOccurrenceComment, p.WorkflowName, data.Links.Run, p.Organization, p.Repository, data.EventName, p.Sender, n)
+	if result != nil {
+		comment += result.Markdown()
+	}
+	if _, _, err := issues.CreateComment(ctx, r.owner, r.name, issue.GetNumber(), &github.IssueComment{
+		Body: github.String(comment),
+	}); err != nil {
+		return nil, err
+	}
+
+	w.logger.Infow("issue updated", "repository", r.String(), "issue", issue.GetNumber(), "occurrences", n)
+	return issue, nil
+}
+
+// findWorkflowIssue returns the open not-valid issue carrying the marker, nil when there is none
+func (w *WorkflowAction) findWorkflowIssue(ctx context.Context, r *issueRepository, marker string) (*github.Issue, error) {
+	return w.findIssue(ctx, r, utils.LabelNotValid, marker)
+}
+
+// findIssue returns the open issue with the label that carries the marker, nil when there is none
+func (w *WorkflowAction) findIssue(ctx context.Context, r *issueRepository, label, marker string) (*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      []string{label},
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		issues, resp, err := r.client.GetV3Client().Issues.ListByRepo(ctx, r.owner, r.name, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if strings.Contains(issue.GetBody(), marker) {
+				return issue, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
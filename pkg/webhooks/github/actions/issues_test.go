@@ -0,0 +1,51 @@
+package actions
+
+import "testing"
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "no counter", body: "## Actions Controller\n", want: 1},
+		{name: "counter", body: "## Actions Controller\n\n**Occurrences:** 12\n<!-- marker -->\n", want: 12},
+		{name: "counter in text", body: "see **Occurrences:** 3 below\n", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := occurrences(tt.body); got != tt.want {
+				t.Errorf("occurrences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithOccurrences(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		n    int
+		want string
+	}{
+		{name: "append", body: "body\n\n", n: 1, want: "body\n\n**Occurrences:** 1\n"},
+		{name: "replace", body: "body\n\n**Occurrences:** 1\n<!-- marker -->\n", n: 2, want: "body\n\n**Occurrences:** 2\n<!-- marker -->\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withOccurrences(tt.body, tt.n)
+			if got != tt.want {
+				t.Errorf("withOccurrences() = %q, want %q", got, tt.want)
+			}
+			if occurrences(got) != tt.n {
+				t.Errorf("occurrences(withOccurrences()) = %v, want %v", occurrences(got), tt.n)
+			}
+		})
+	}
+}
+
+func TestIssueMarker(t *testing.T) {
+	if got := issueMarker("mo-octocat", "flutter-template", 42); got != "<!-- actions-controller:workflow mo-octocat/flutter-template/42 -->" {
+		t.Errorf("issueMarker() = %v", got)
+	}
+}
@@ -0,0 +1,267 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// labelVariables are the variables available in ${{ }} label expressions
+var labelVariables = map[string]func(p *WorkflowActionParams, registration *ValidatorData) string{
+	"org_name":            func(p *WorkflowActionParams, _ *ValidatorData) string { return p.Organization },
+	"repo_name":           func(p *WorkflowActionParams, _ *ValidatorData) string { return p.Repository },
+	"workflow_name":       func(p *WorkflowActionParams, _ *ValidatorData) string { return p.WorkflowName },
+	"workflow_id":         func(p *WorkflowActionParams, _ *ValidatorData) string { return strconv.FormatInt(p.WorkflowID, 10) },
+	"sender":              func(p *WorkflowActionParams, _ *ValidatorData) string { return p.Sender },
+	"head_branch":         func(p *WorkflowActionParams, _ *ValidatorData) string { return p.HeadBranch },
+	"repo.visibility":     func(p *WorkflowActionParams, _ *ValidatorData) string { return p.Repo.Visibility },
+	"github.event.type":   func(p *WorkflowActionParams, _ *ValidatorData) string { return string(p.WebhookEvent) },
+	"github.event.action": func(p *WorkflowActionParams, _ *ValidatorData) string { return p.Action },
+	"registration.use_case": func(_ *WorkflowActionParams, r *ValidatorData) string {
+		if r == nil {
+			return ""
+		}
+		return r.UseCase
+	},
+	"registration.owner": func(_ *WorkflowActionParams, r *ValidatorData) string {
+		if r == nil {
+			return ""
+		}
+		return r.Owner
+	},
+}
+
+var (
+	labelExpression = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+	labelColor      = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+)
+
+// stringToIssueLabelHook lets issue labels be configured as a plain name
+func stringToIssueLabelHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(config.IssueLabel{}) {
+		return data, nil
+	}
+	return map[string]any{"name": data}, nil
+}
+
+// labelTerm is a variable or a quoted string literal
+type labelTerm struct {
+	literal  string
+	variable string
+}
+
+// labelTemplate is a label name with ${{ }} expressions. An expression is a list of terms separated by ||,
+// the first term that is not empty is used, e.g. ${{ registration.owner || sender }} or ${{ head_branch || 'none' }}.
+type labelTemplate struct {
+	config.IssueLabel
+	parts []string
+	exprs [][]labelTerm
+}
+
+func parseLabel(l config.IssueLabel) (*labelTemplate, error) {
+	if strings.TrimSpace(l.Name) == "" {
+		return nil, fmt.Errorf(utils.ErrInvalidIssueLabel, l.Name, "name is required")
+	}
+	l.Color = strings.TrimPrefix(l.Color, "#")
+	if l.Color == "" {
+		l.Color = utils.DefaultLabelColor
+	}
+	if !labelColor.MatchString(l.Color) {
+		return nil, fmt.Errorf(utils.ErrInvalidIssueLabel, l.Name, "color must be a hex color like d73a4a")
+	}
+
+	t := &labelTemplate{IssueLabel: l}
+	rest := l.Name
+	for {
+		loc := labelExpression.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			break
+		}
+		expr, err := parseLabelExpression(rest[loc[2]:loc[3]])
+		if err != nil {
+			return nil, fmt.Errorf(utils.ErrInvalidIssueLabel, l.Name, err.Error())
+		}
+		t.parts = append(t.parts, rest[:loc[0]])
+		t.exprs = append(t.exprs, expr)
+		rest = rest[loc[1]:]
+	}
+	if strings.Contains(rest, "${{") {
+		return nil, fmt.Errorf(utils.ErrInvalidIssueLabel, l.Name, "unterminated expression")
+	}
+	t.parts = append(t.parts, rest)
+	return t, nil
+}
+
+func parseLabelExpression(expr string) ([]labelTerm, error) {
+	var terms []labelTerm
+	for _, term := range strings.Split(expr, "||") {
+		term = strings.TrimSpace(term)
+		switch {
+		case len(term) >= 2 && strings.HasPrefix(term, "'") && strings.HasSuffix(term, "'"):
+			terms = append(terms, labelTerm{literal: term[1 : len(term)-1]})
+		case labelVariables[term] != nil:
+			terms = append(terms, labelTerm{variable: term})
+		case term == "":
+			return nil, fmt.Errorf("empty expression")
+		default:
+			return nil, fmt.Errorf("unknown variable %s", term)
+		}
+	}
+	return terms, nil
+}
+
+// render evaluates the expressions, labels longer than GitHub allows are truncated
+func (t *labelTemplate) render(p *WorkflowActionParams, registration *ValidatorData) string {
+	var b strings.Builder
+	for i, expr := range t.exprs {
+		b.WriteString(t.parts[i])
+		for _, term := range expr {
+			value := term.literal
+			if term.variable != "" {
+				value = labelVariables[term.variable](p, registration)
+			}
+			if value != "" {
+				b.WriteString(value)
+				break
+			}
+		}
+	}
+	b.WriteString(t.parts[len(t.parts)-1])
+
+	name := strings.TrimSpace(b.String())
+	if runes := []rune(name); len(runes) > utils.MaxLabelLength {
+		name = string(runes[:utils.MaxLabelLength])
+	}
+	return name
+}
+
+func parseLabels(labels []config.IssueLabel) ([]*labelTemplate, error) {
+	if len(labels) == 0 {
+		labels = []config.IssueLabel{{Name: utils.DefaultIssueLabel}}
+	}
+	templates := make([]*labelTemplate, 0, len(labels))
+	for _, l := range labels {
+		t, err := parseLabel(l)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// issueLabels renders the configured labels of an event, empty and duplicate labels are dropped. The not-valid
+// label is always added, it marks the issues of open violations.
+func (w *WorkflowAction) issueLabels(p *WorkflowActionParams, result *ValidationResult) []config.IssueLabel {
+	var registration *ValidatorData
+	if result != nil {
+		registration = result.Registration()
+	}
+
+	seen := make(map[string]bool)
+	var labels []config.IssueLabel
+	for _, t := range w.labels {
+		name := t.render(p, registration)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		labels = append(labels, config.IssueLabel{Name: name, Color: t.Color, Description: t.Description})
+	}
+	if !seen[utils.LabelNotValid] {
+		labels = append(labels, config.IssueLabel{Name: utils.LabelNotValid, Color: utils.LabelNotValidColor})
+	}
+	return labels
+}
+
+// ensureLabels creates the labels that do not exist in the repository yet, labels that are known to exist are
+// cached. The labels of the repository are listed instead of looked up by name, as GitHub compares label names
+// case-insensitively and names may contain characters that do not fit a URL path.
+func (w *WorkflowAction) ensureLabels(ctx context.Context, r *issueRepository, labels []config.IssueLabel) ([]string, error) {
+	names := make([]string, 0, len(labels))
+	var missing []config.IssueLabel
+	w.labelMu.Lock()
+	for _, l := range labels {
+		names = append(names, l.Name)
+		if !w.knownLabels[labelKey(r, l.Name)] {
+			missing = append(missing, l)
+		}
+	}
+	w.labelMu.Unlock()
+	if len(missing) == 0 {
+		return names, nil
+	}
+
+	existing, err := listLabels(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := r.client.GetV3Client().Issues
+	for _, l := range missing {
+		if !existing[strings.ToLower(l.Name)] {
+			label := &github.Label{Name: github.String(l.Name), Color: github.String(l.Color)}
+			if l.Description != "" {
+				label.Description = github.String(l.Description)
+			}
+			// a concurrent event may have created the label in the meantime
+			if _, _, err := issues.CreateLabel(ctx, r.owner, r.name, label); err != nil && !labelExists(err) {
+				return nil, err
+			}
+			w.logger.Infow("label created", "repository", r.String(), "label", l.Name, "color", l.Color)
+		}
+
+		w.labelMu.Lock()
+		w.knownLabels[labelKey(r, l.Name)] = true
+		w.labelMu.Unlock()
+	}
+	return names, nil
+}
+
+func labelKey(r *issueRepository, name string) string {
+	return strings.ToLower(r.String() + ":" + name)
+}
+
+// listLabels returns the lower-cased names of the labels of the repository
+func listLabels(ctx context.Context, r *issueRepository) (map[string]bool, error) {
+	labels := make(map[string]bool)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := r.client.GetV3Client().Issues.ListLabels(ctx, r.owner, r.name, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range page {
+			labels[strings.ToLower(label.GetName())] = true
+		}
+		if resp.NextPage == 0 {
+			return labels, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// labelExists reports whether creating a label failed because a label of the same name exists, other validation
+// failures like an invalid color are errors
+func labelExists(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil || errResp.Response.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	for _, e := range errResp.Errors {
+		if e.Code == utils.GithubErrorAlreadyExists {
+			return true
+		}
+	}
+	return false
+}
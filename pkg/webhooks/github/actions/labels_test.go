@@ -0,0 +1,175 @@
+package actions
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+func TestParseLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		label   config.IssueLabel
+		wantErr bool
+	}{
+		{name: "plain", label: config.IssueLabel{Name: "actions"}},
+		{name: "expressions", label: config.IssueLabel{Name: "${{ org_name }}/${{ repo_name }}"}},
+		{name: "fallback", label: config.IssueLabel{Name: "owner: ${{ registration.owner || sender || 'unknown' }}", Color: "#d73a4a"}},
+		{name: "empty name", label: config.IssueLabel{Name: " "}, wantErr: true},
+		{name: "unknown variable", label: config.IssueLabel{Name: "${{ github.event.sender }}"}, wantErr: true},
+		{name: "empty expression", label: config.IssueLabel{Name: "${{ }}"}, wantErr: true},
+		{name: "unterminated", label: config.IssueLabel{Name: "${{ org_name"}, wantErr: true},
+		{name: "invalid color", label: config.IssueLabel{Name: "actions", Color: "red"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseLabel(tt.label)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLabel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLabelTemplate_render(t *testing.T) {
+	p := &WorkflowActionParams{
+		Organization: "mo-octocat",
+		Repository:   "flutter-template",
+		WebhookEvent: "workflow_run",
+		Action:       "requested",
+		Sender:       "octocat",
+	}
+	tests := []struct {
+		name         string
+		label        string
+		registration *ValidatorData
+		want         string
+	}{
+		{name: "repository", label: "${{ org_name }}/${{ repo_name }}", want: "mo-octocat/flutter-template"},
+		{name: "event", label: "${{github.event.type}}:${{github.event.action}}", want: "workflow_run:requested"},
+		{name: "fallback to sender", label: "owner: ${{ registration.owner || sender }}", want: "owner: octocat"},
+		{name: "registration", label: "owner: ${{ registration.owner || sender }}", registration: &ValidatorData{Owner: "team-a"}, want: "owner: team-a"},
+		{name: "literal", label: "${{ head_branch || 'no-branch' }}", want: "no-branch"},
+		{name: "empty", label: "${{ head_branch }}", want: ""},
+		{name: "truncated", label: strings.Repeat("a", 40) + "-${{ repo_name }}", want: strings.Repeat("a", 40) + "-flutter-t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := parseLabel(config.IssueLabel{Name: tt.label})
+			if err != nil {
+				t.Fatalf("parseLabel() error = %v", err)
+			}
+			if got := l.render(p, tt.registration); got != tt.want {
+				t.Errorf("render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_issueLabels(t *testing.T) {
+	var labels []config.IssueLabel
+	err := decodeArg(map[string]any{"issue_labels": []any{
+		"${{ org_name }}/${{ repo_name }}",
+		map[string]any{"name": "${{ head_branch }}", "color": "0e8a16"},
+		map[string]any{"name": "NOT-VALID", "description": "repository is not registered"},
+		"${{ org_name }}/${{ repo_name }}",
+	}}, "issue_labels", &labels)
+	if err != nil {
+		t.Fatalf("decodeArg() error = %v", err)
+	}
+
+	templates, err := parseLabels(labels)
+	if err != nil {
+		t.Fatalf("parseLabels() error = %v", err)
+	}
+	w := &WorkflowAction{labels: templates}
+
+	got := w.issueLabels(&WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template"}, nil)
+	want := []config.IssueLabel{
+		{Name: "mo-octocat/flutter-template", Color: "ededed"},
+		{Name: "NOT-VALID", Color: "ededed", Description: "repository is not registered"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issueLabels() = %+v, want %+v", got, want)
+	}
+
+	defaults, err := parseLabels(nil)
+	if err != nil {
+		t.Fatalf("parseLabels() error = %v", err)
+	}
+	w = &WorkflowAction{labels: defaults}
+	got = w.issueLabels(&WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template"}, nil)
+	want = []config.IssueLabel{
+		{Name: "mo-octocat/flutter-template", Color: "ededed"},
+		{Name: "not-valid", Color: "d73a4a"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issueLabels() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWorkflowAction_ensureLabels(t *testing.T) {
+	const labels = "/repos/mo-octocat/actions-registry/labels"
+
+	tests := []struct {
+		name     string
+		existing []any
+		status   int
+		response map[string]any
+		created  int
+		wantErr  bool
+	}{
+		{name: "existing label with a different case", existing: []any{map[string]any{"name": "Mo-Octocat/Flutter-Template"}}},
+		{name: "missing label", existing: []any{}, status: http.StatusCreated, response: map[string]any{}, created: 1},
+		{
+			name:     "created concurrently",
+			existing: []any{},
+			status:   http.StatusUnprocessableEntity,
+			response: map[string]any{"message": "Validation Failed", "errors": []any{map[string]any{"resource": "Label", "code": "already_exists", "field": "name"}}},
+			created:  1,
+		},
+		{
+			name:     "invalid label",
+			existing: []any{},
+			status:   http.StatusUnprocessableEntity,
+			response: map[string]any{"message": "Validation Failed", "errors": []any{map[string]any{"resource": "Label", "code": "invalid", "field": "color"}}},
+			created:  1,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorkflowAction(t, nil, nil)
+			w.fake.reply(http.MethodGet, labels, http.StatusOK, tt.existing)
+			w.fake.reply(http.MethodPost, labels, tt.status, tt.response)
+
+			r, err := w.issueRepository(testOrganization + "/" + testRepository)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the name contains a slash, it must not end up in a URL path
+			_, err = w.ensureLabels(context.Background(), r, []config.IssueLabel{{Name: "mo-octocat/flutter-template", Color: "ededed"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ensureLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(w.fake.called(http.MethodPost, labels)); got != tt.created {
+				t.Errorf("ensureLabels() created %d labels, want %d", got, tt.created)
+			}
+
+			// known labels are neither listed nor created again
+			if tt.wantErr {
+				return
+			}
+			if _, err := w.ensureLabels(context.Background(), r, []config.IssueLabel{{Name: "MO-OCTOCAT/flutter-template"}}); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(w.fake.called(http.MethodGet, labels)); got != 1 {
+				t.Errorf("ensureLabels() listed the labels %d times, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.tools.sap/actions-rollout-app/pkg/notify"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// notificationKinds are the audit actions that are sent to the notification targets
+var notificationKinds = map[string]bool{
+	utils.AuditActionWouldDisable:         true,
+	utils.AuditActionDisabled:             true,
+	utils.AuditActionRepositoryDisabled:   true,
+	utils.AuditActionOrganizationDisabled: true,
+	utils.AuditActionNotified:             true,
+	utils.AuditActionIssueCreated:         true,
+	utils.AuditActionIssueClosed:          true,
+	utils.AuditActionReenabled:            true,
+	utils.AuditActionCancelled:            true,
+	utils.AuditActionExempted:             true,
+}
+
+// Policies returns the names of the policies the registrations violate
+func (v *ValidationResult) Policies() []string {
+	if v == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var policies []string
+	for _, file := range v.Files {
+		for _, failure := range file.Failures {
+			if !strings.HasPrefix(failure, "policy ") {
+				continue
+			}
+			name := strings.SplitN(strings.TrimPrefix(failure, "policy "), ":", 2)[0]
+			if !seen[name] {
+				seen[name] = true
+				policies = append(policies, name)
+			}
+		}
+	}
+	return policies
+}
+
+func workflowNotification(enterpriseURL, kind string, p *WorkflowActionParams, result *ValidationResult) notify.Notification {
+	n := notify.Notification{
+		Kind:         kind,
+		Organization: p.Organization,
+		Repository:   p.Repository,
+		Workflow:     p.WorkflowName,
+		Policies:     result.Policies(),
+		Title:        fmt.Sprintf(utils.NotificationTitle, kind, p.Organization, p.Repository, p.WorkflowName),
+		URL:          issueTemplateData(enterpriseURL, "run", p, result).Links.Run,
+	}
+	if registration := result.Registration(); registration != nil {
+		n.Contact = registration.ContactEmail
+	}
+	if result != nil {
+		if err := result.Err(); err != nil {
+			n.Text = err.Error()
+		}
+	}
+	return n
+}
+
+// notify sends the notification in the background, deliveries are retried and must not hold up webhook handling
+func (w *WorkflowAction) notify(kind string, p *WorkflowActionParams, result *ValidationResult) {
+	if w.notifier == nil || !notificationKinds[kind] {
+		return
+	}
+	w.send(workflowNotification(w.client.ServerInfo().EnterpriseURL, kind, p, result))
+}
+
+func (w *WorkflowAction) send(n notify.Notification) {
+	if w.notifier == nil {
+		return
+	}
+	go w.notifier.Notify(context.Background(), n)
+}
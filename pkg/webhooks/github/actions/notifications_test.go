@@ -0,0 +1,35 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestWorkflowNotification(t *testing.T) {
+	p := &WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template", WorkflowName: "build", RunID: 7}
+	result := &ValidationResult{Organization: "mo-octocat", Repository: "flutter-template", Files: []FileResult{
+		{Path: "a.yml", Applies: true, Failures: []string{"policy internal-only: only internal repositories", "invalid contact email or empty"}},
+		{Path: "b.yml", Applies: true, Failures: []string{"policy internal-only: only internal repositories", "policy owner: owner is required"}},
+		{Path: "c.yml", Applies: true, Registration: &ValidatorData{ContactEmail: "team@example.com"}},
+	}}
+
+	n := workflowNotification("https://octodemo.com", utils.AuditActionDisabled, p, result)
+	if want := []string{"internal-only", "owner"}; !reflect.DeepEqual(n.Policies, want) {
+		t.Errorf("Policies = %v, want %v", n.Policies, want)
+	}
+	if n.Title != "[disabled] mo-octocat/flutter-template workflow build" {
+		t.Errorf("Title = %q", n.Title)
+	}
+	if n.URL != "https://octodemo.com/mo-octocat/flutter-template/actions/runs/7" {
+		t.Errorf("URL = %q", n.URL)
+	}
+	if n.Contact != "team@example.com" {
+		t.Errorf("Contact = %q", n.Contact)
+	}
+
+	if n := workflowNotification("https://octodemo.com", utils.AuditActionReenabled, p, nil); n.Text != "" || n.Policies != nil {
+		t.Errorf("workflowNotification() without result = %+v", n)
+	}
+}
@@ -0,0 +1,227 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// disabledWorkflow is the persisted record of a workflow disabled by the controller
+type disabledWorkflow struct {
+	Organization string    `json:"organization"`
+	Repository   string    `json:"repository"`
+	WorkflowID   int64     `json:"workflow_id"`
+	WorkflowName string    `json:"workflow_name"`
+	Reason       string    `json:"reason"`
+	Issue        int       `json:"issue,omitempty"`
+	DisabledAt   time.Time `json:"disabled_at"`
+	// Event is the event the workflow was disabled on, policies are evaluated against it again
+	Event    *WorkflowActionParams `json:"event,omitempty"`
+	Policies []string              `json:"policies,omitempty"`
+	Findings bool                  `json:"findings,omitempty"`
+}
+
+func disableReason(result *ValidationResult) string {
+	if result == nil {
+		return ""
+	}
+	if err := result.Err(); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// workflowKey scopes the records of a workflow to the action in the same way as violations
+func (w *WorkflowAction) workflowKey(org, repo string, workflowID int64) string {
+	return fmt.Sprintf("%s/%s:%s/%s:%d", w.organization, w.repository, org, repo, workflowID)
+}
+
+// markDisabled remembers a disabled workflow together with the reason it was disabled for, an existing record keeps
+// its time and issue. A nil result keeps the recorded reason.
+func (w *WorkflowAction) markDisabled(p *WorkflowActionParams, result *ValidationResult, issue int) {
+	key := w.workflowKey(p.Organization, p.Repository, p.WorkflowID)
+
+	d := &disabledWorkflow{}
+	found, err := w.state.Get(utils.StateBucketDisabledWorkflows, key, d)
+	if err != nil {
+		w.logger.Errorw("error reading disabled workflow", "key", key, "error", err)
+	}
+	if !found || err != nil {
+		d = &disabledWorkflow{
+			Organization: p.Organization,
+			Repository:   p.Repository,
+			WorkflowID:   p.WorkflowID,
+			DisabledAt:   time.Now().UTC(),
+		}
+	}
+	d.WorkflowName = p.WorkflowName
+	if result != nil {
+		d.Reason = disableReason(result)
+		d.Policies = result.Policies()
+		d.Findings = len(result.Findings) > 0
+		event := *p
+		d.Event = &event
+	}
+	if issue != 0 {
+		d.Issue = issue
+	}
+
+	if err := w.state.Put(utils.StateBucketDisabledWorkflows, key, d); err != nil {
+		w.logger.Errorw("error recording disabled workflow", "key", key, "error", err)
+	}
+}
+
+// attachIssue links the issue opened after disabling to the disabled workflow records
+func (w *WorkflowAction) attachIssue(p *WorkflowActionParams, workflows map[int64]string, issue int) {
+	if w.enforcementScope() != utils.EnforcementScopeWorkflow {
+		return
+	}
+	for workflowID, workflowName := range workflows {
+		params := *p
+		params.WorkflowID = workflowID
+		params.WorkflowName = workflowName
+		w.markDisabled(&params, nil, issue)
+	}
+}
+
+// disabledWorkflows returns the workflows disabled by this action grouped by organization/repository
+func (w *WorkflowAction) disabledWorkflows() (map[string][]*disabledWorkflow, error) {
+	prefix := fmt.Sprintf("%s/%s:", w.organization, w.repository)
+	repos := make(map[string][]*disabledWorkflow)
+	for _, key := range w.state.Keys(utils.StateBucketDisabledWorkflows) {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		d := &disabledWorkflow{}
+		if _, err := w.state.Get(utils.StateBucketDisabledWorkflows, key, d); err != nil {
+			return nil, err
+		}
+		repo := fmt.Sprintf("%s/%s", d.Organization, d.Repository)
+		repos[repo] = append(repos[repo], d)
+	}
+	return repos, nil
+}
+
+// reenableWorkflows validates every repository with disabled workflows again and re-enables the
+// workflows of the repositories that are valid now. Workflows disabled for a policy are validated against the
+// event they were disabled on; workflows disabled for analysis findings are left to the next run of the workflow,
+// as the findings are only known once the workflow file is analyzed again.
+func (w *WorkflowAction) reenableWorkflows(ctx context.Context) error {
+	repos, err := w.disabledWorkflows()
+	if err != nil {
+		return err
+	}
+
+	for _, workflows := range repos {
+		org, repo := workflows[0].Organization, workflows[0].Repository
+		var repoResult *ValidationResult
+		var repoErr error
+
+		for _, d := range workflows {
+			if d.Findings || (len(d.Policies) > 0 && d.Event == nil) {
+				w.logger.Debugw("workflow not re-validated, waiting for its next run", "organization", org, "repository", repo, "workflow_id", d.WorkflowID)
+				continue
+			}
+
+			result, err := repoResult, repoErr
+			if d.Event != nil {
+				result, err = w.repoAction().HandleRepo(ctx, &RepoActionParams{
+					ValidationOrganization: org,
+					ValidationRepository:   repo,
+					Event:                  d.Event,
+				})
+			} else if repoResult == nil {
+				repoResult, repoErr = w.repoAction().HandleRepo(ctx, &RepoActionParams{
+					ValidationOrganization: org,
+					ValidationRepository:   repo,
+				})
+				result, err = repoResult, repoErr
+			}
+			if err != nil {
+				w.logger.Debugw("repository still not valid", "organization", org, "repository", repo, "workflow_id", d.WorkflowID, "error", err)
+				continue
+			}
+
+			if err := w.reenableWorkflow(ctx, d, result); err != nil {
+				w.logger.Errorw("error re-enabling workflow", "organization", org, "repository", repo, "workflow_id", d.WorkflowID, "error", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *WorkflowAction) reenableWorkflow(ctx context.Context, d *disabledWorkflow, result *ValidationResult) error {
+	if err := w.enableWorkflow(ctx, d.Organization, d.Repository, d.WorkflowID); err != nil {
+		return err
+	}
+	w.logger.Infow("workflow re-enabled", "organization", d.Organization, "repository", d.Repository, "workflow_id", d.WorkflowID)
+
+	key := w.workflowKey(d.Organization, d.Repository, d.WorkflowID)
+	record := &openIssue{}
+	found, err := w.state.Get(utils.StateBucketOpenIssues, key, record)
+	if err != nil {
+		return err
+	}
+	if !found {
+		record = &openIssue{Organization: d.Organization, Repository: d.Repository, Issue: d.Issue}
+	}
+	for _, ref := range record.refs(w.centralRepository()) {
+		r, err := w.issueRepository(ref.Repository)
+		if err != nil {
+			return err
+		}
+		if err := w.closeWorkflowIssue(ctx, r, ref.Number, fmt.Sprintf(utils.RemediationComment, d.Organization, d.Repository, d.WorkflowName, d.WorkflowID)); err != nil {
+			return err
+		}
+	}
+
+	p := &WorkflowActionParams{
+		WorkflowName: d.WorkflowName,
+		WorkflowID:   d.WorkflowID,
+		Organization: d.Organization,
+		Repository:   d.Repository,
+	}
+	w.recordAudit(p, utils.AuditActionReenabled, result)
+	w.clearViolation(p)
+	if err := w.resolveCheckRun(ctx, d.Organization, d.Repository, d.WorkflowID); err != nil {
+		w.logger.Errorw("error resolving check run", "organization", d.Organization, "repository", d.Repository, "workflow_id", d.WorkflowID, "error", err)
+	}
+	if err := w.state.Delete(utils.StateBucketOpenIssues, key); err != nil {
+		return err
+	}
+
+	return w.state.Delete(utils.StateBucketDisabledWorkflows, key)
+}
+
+func (w *WorkflowAction) enableWorkflow(ctx context.Context, org, repo string, workflowID int64) error {
+	repoClient, err := w.client.ForRepository(org, repo)
+	if err != nil {
+		return err
+	}
+
+	resp, err := repoClient.GetV3Client().Actions.EnableWorkflowByID(ctx, org, repo, workflowID)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New(strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+func (w *WorkflowAction) remediationJob() scheduler.Job {
+	return scheduler.Job{
+		Name:     fmt.Sprintf("remediation-%s/%s", w.organization, w.repository),
+		Interval: w.remediation.Interval,
+		Run:      w.reenableWorkflows,
+	}
+}
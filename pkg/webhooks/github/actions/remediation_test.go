@@ -0,0 +1,122 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/pkg/analysis"
+	"github.tools.sap/actions-rollout-app/pkg/state"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestWorkflowAction_markDisabled(t *testing.T) {
+	s, err := state.New(zap.NewNop().Sugar(), nil)
+	if err != nil {
+		t.Fatalf("state.New() error = %v", err)
+	}
+	w := &WorkflowAction{logger: zap.NewNop().Sugar(), organization: "mo-octocat", repository: "actions-control", state: s}
+	other := &WorkflowAction{logger: zap.NewNop().Sugar(), organization: "mo-octocat", repository: "other-control", state: s}
+
+	expired := &ValidationResult{
+		Organization: "mo-octocat",
+		Repository:   "flutter-template",
+		Files:        []FileResult{{Path: "registrations/flutter.yml", Applies: true, Failures: []string{"registration expired", "policy main-only: branch dev"}}},
+	}
+	p := &WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template", WorkflowID: 42, WorkflowName: "build"}
+	w.markDisabled(p, expired, 0)
+	w.markDisabled(p, nil, 7)
+	w.markDisabled(&WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template", WorkflowID: 43, WorkflowName: "ci"}, expired, 8)
+	other.markDisabled(&WorkflowActionParams{Organization: "mo-octocat", Repository: "api", WorkflowID: 1}, &ValidationResult{}, 1)
+
+	repos, err := w.disabledWorkflows()
+	if err != nil {
+		t.Fatalf("disabledWorkflows() error = %v", err)
+	}
+	if len(repos) != 1 {
+		t.Fatalf("disabledWorkflows() = %v, want a single repository", repos)
+	}
+
+	workflows := repos["mo-octocat/flutter-template"]
+	if len(workflows) != 2 {
+		t.Fatalf("disabledWorkflows() = %v, want 2 workflows", workflows)
+	}
+
+	tests := []struct {
+		name      string
+		got       *disabledWorkflow
+		wantID    int64
+		wantIssue int
+	}{
+		{name: "updated record keeps reason", got: workflows[0], wantID: 42, wantIssue: 7},
+		{name: "second workflow", got: workflows[1], wantID: 43, wantIssue: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.WorkflowID != tt.wantID || tt.got.Reason != disableReason(expired) || tt.got.Issue != tt.wantIssue {
+				t.Errorf("disabledWorkflow = %+v", tt.got)
+			}
+			if !reflect.DeepEqual(tt.got.Policies, []string{"main-only"}) || tt.got.Event == nil || tt.got.Event.WorkflowID != tt.wantID {
+				t.Errorf("disabledWorkflow policies = %v, event = %+v", tt.got.Policies, tt.got.Event)
+			}
+			if tt.got.DisabledAt.IsZero() {
+				t.Errorf("disabledWorkflow.DisabledAt is not set")
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_reenableWorkflows(t *testing.T) {
+	w := newTestWorkflowAction(t, map[string]any{
+		"policies": []any{map[string]any{"name": "main-only", "expression": `event.head_branch == "main"`}},
+	}, nil)
+	w.fake.files(testOrganization, testRepository, "registrations", map[string]string{"flutter.yml": testRegistration})
+	for _, id := range []int64{1, 2, 3, 4} {
+		w.fake.reply(http.MethodPut, fmt.Sprintf("/repos/mo-octocat/flutter-template/actions/workflows/%d/enable", id), http.StatusNoContent, nil)
+	}
+
+	event := func(workflowID int64, branch string) *WorkflowActionParams {
+		return &WorkflowActionParams{Organization: testOrganization, Repository: "flutter-template", WorkflowID: workflowID, WorkflowName: "build", HeadBranch: branch}
+	}
+	policyResult := &ValidationResult{Files: []FileResult{{Applies: true, Failures: []string{"policy main-only: branch is not main"}}}}
+	w.markDisabled(event(1, "dev"), policyResult, 0)
+	w.markDisabled(event(2, "main"), policyResult, 0)
+	w.markDisabled(event(3, "main"), &ValidationResult{Findings: []analysis.Finding{{Rule: "unpinned-action"}}}, 0)
+	w.markDisabled(event(4, "main"), policyResult, 0)
+	record := &disabledWorkflow{}
+	key := w.workflowKey(testOrganization, "flutter-template", 4)
+	if _, err := w.state.Get(utils.StateBucketDisabledWorkflows, key, record); err != nil {
+		t.Fatal(err)
+	}
+	record.Event = nil
+	if err := w.state.Put(utils.StateBucketDisabledWorkflows, key, record); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.reenableWorkflows(context.Background()); err != nil {
+		t.Fatalf("reenableWorkflows() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		workflowID  int64
+		wantEnabled bool
+	}{
+		{name: "policy still violated by the recorded event", workflowID: 1},
+		{name: "policy satisfied by the recorded event", workflowID: 2, wantEnabled: true},
+		{name: "analysis findings", workflowID: 3},
+		{name: "policy without recorded event", workflowID: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabled := len(w.fake.called(http.MethodPut, fmt.Sprintf("/repos/mo-octocat/flutter-template/actions/workflows/%d/enable", tt.workflowID))) > 0
+			if enabled != tt.wantEnabled {
+				t.Errorf("workflow %d enabled = %v, want %v", tt.workflowID, enabled, tt.wantEnabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v2"
+
+	"github.tools.sap/actions-rollout-app/pkg/notify"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+type registrationFile struct {
+	Path   string
+	Source string
+	Data   ValidatorData
+}
+
+func (w *WorkflowAction) jobs() []scheduler.Job {
+	var jobs []scheduler.Job
+	if w.expiry.ReminderDays > 0 {
+		interval := w.expiry.ReminderInterval
+		if interval <= 0 {
+			interval = 24 * time.Hour
+		}
+		jobs = append(jobs, scheduler.Job{
+			Name:     fmt.Sprintf("renewal-reminders-%s/%s", w.organization, w.repository),
+			Interval: interval,
+			Run:      w.remindRenewals,
+		})
+	}
+	if w.escalation.Enabled {
+		jobs = append(jobs, w.escalationJob())
+	}
+	if w.exemptions.enabled() {
+		jobs = append(jobs, w.exemptionJob())
+	}
+	if len(w.runnerGroups.Groups) > 0 && len(w.runnerGroups.Organizations) > 0 {
+		jobs = append(jobs, w.runnerGroupsJob())
+	}
+	if w.remediation.Enabled {
+		jobs = append(jobs, w.remediationJob())
+	}
+	if w.digest.Enabled {
+		jobs = append(jobs, w.digestJob())
+	}
+	jobs = append(jobs, w.issueResolutionJob())
+	return jobs
+}
+
+// loadRegistrations reads every registration file from the configured files paths, unparsable ones are skipped
+func (r *RepoAction) loadRegistrations(ctx context.Context) ([]registrationFile, error) {
+	return r.readRegistrations(ctx, false)
+}
+
+// readRegistrations reads every registration file, strict fails on unparsable registrations instead of skipping them
+func (r *RepoAction) readRegistrations(ctx context.Context, strict bool) ([]registrationFile, error) {
+	if r.filesPath == nil {
+		return nil, nil
+	}
+
+	var registrations []registrationFile
+	for _, path := range *r.filesPath {
+		content, err := r.getContents(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range content {
+			if !r.isFileValid(file) {
+				continue
+			}
+
+			filePath := fmt.Sprintf("%s/%s", path, file.GetName())
+			bytes, err := r.readFile(ctx, filePath)
+			if err != nil {
+				return nil, err
+			}
+
+			var data ValidatorData
+			if err := yaml.Unmarshal(bytes, &data); err != nil {
+				if strict {
+					return nil, fmt.Errorf(utils.ErrRegistrationUnparsable, filePath, err)
+				}
+				r.logger.Warnw("skipping unparsable registration", "file", filePath, "error", err)
+				continue
+			}
+			registrations = append(registrations, registrationFile{Path: filePath, Source: utils.RegistrationSourceCentral, Data: data})
+		}
+	}
+
+	return registrations, nil
+}
+
+// expiringRegistrations returns the registrations expiring between now and the given number of days
+func expiringRegistrations(registrations []registrationFile, now time.Time, days int) []registrationFile {
+	horizon := now.AddDate(0, 0, days)
+
+	var expiring []registrationFile
+	for _, registration := range registrations {
+		expires, ok, err := registration.Data.ExpiresAt()
+		if err != nil || !ok {
+			continue
+		}
+		if expires.AddDate(0, 0, 1).Before(now) || expires.After(horizon) {
+			continue
+		}
+		expiring = append(expiring, registration)
+	}
+	return expiring
+}
+
+// remindRenewals opens a renewal reminder issue for every central registration that is about to expire. In-repo
+// registrations are not reminded, they are only read for the repository of an event and would need every
+// repository of the enterprise to be scanned.
+func (w *WorkflowAction) remindRenewals(ctx context.Context) error {
+	registrations, err := w.repoAction().loadRegistrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	expiring := expiringRegistrations(registrations, time.Now(), w.expiry.ReminderDays)
+	if len(expiring) == 0 {
+		return nil
+	}
+
+	open, err := w.openIssueTitles(ctx, utils.LabelRenewalReminder)
+	if err != nil {
+		return err
+	}
+
+	for _, registration := range expiring {
+		title := fmt.Sprintf(utils.RegistrationRenewalTitle, registration.Path, registration.Data.Expires)
+		if open[title] {
+			continue
+		}
+
+		message := fmt.Sprintf(utils.RegistrationRenewalMessage,
+			registration.Path,
+			w.client.ServerInfo().EnterpriseURL,
+			w.organization,
+			w.repository,
+			registration.Path,
+			registration.Data.Expires,
+			registration.Data.Owner,
+			registration.Data.ContactEmail,
+			registration.Data.UseCase)
+
+		assignees := *w.assignees
+		if registration.Data.Owner != "" {
+			assignees = []string{registration.Data.Owner}
+		}
+
+		if _, err := w.createWorkflowIssue(ctx, title, message, assignees, []string{utils.LabelRenewalReminder}); err != nil {
+			return err
+		}
+		w.logger.Infow("renewal reminder created", "registration", registration.Path, "expires", registration.Data.Expires)
+
+		w.send(notify.Notification{
+			Kind:         utils.NotificationKindRenewalReminder,
+			Organization: organizationFromURL(w.client.ServerInfo().EnterpriseURL, registration.Data.URL),
+			Contact:      registration.Data.ContactEmail,
+			Title:        title,
+			Text:         fmt.Sprintf(utils.RegistrationRenewalText, registration.Path, registration.Data.Expires),
+			URL:          fmt.Sprintf("%s/%s/%s/blob/main/%s", w.client.ServerInfo().EnterpriseURL, w.organization, w.repository, registration.Path),
+		})
+	}
+
+	return nil
+}
+
+// organizationFromURL returns the organization of an organization or repository URL of the enterprise
+func organizationFromURL(enterpriseURL, url string) string {
+	path := strings.TrimPrefix(url, strings.TrimSuffix(enterpriseURL, "/")+"/")
+	if path == url {
+		return ""
+	}
+	return strings.SplitN(strings.Trim(path, "/"), "/", 2)[0]
+}
+
+func (w *WorkflowAction) openIssueTitles(ctx context.Context, label string) (map[string]bool, error) {
+	titles := make(map[string]bool)
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      []string{label},
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		issues, resp, err := w.client.GetV3Client().Issues.ListByRepo(ctx, w.organization, w.repository, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			titles[issue.GetTitle()] = true
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return titles, nil
+}
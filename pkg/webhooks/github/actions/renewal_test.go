@@ -0,0 +1,130 @@
+package actions
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		expires     string
+		gracePeriod time.Duration
+		wantErr     bool
+	}{
+		{name: "no expiry", expires: "", wantErr: false},
+		{name: "not expired", expires: "2023-07-01", wantErr: false},
+		{name: "expires today", expires: "2023-06-15", wantErr: false},
+		{name: "expired", expires: "2023-06-01", wantErr: true},
+		{name: "expired within grace period", expires: "2023-06-10", gracePeriod: 14 * 24 * time.Hour, wantErr: false},
+		{name: "expired after grace period", expires: "2023-05-01", gracePeriod: 14 * 24 * time.Hour, wantErr: true},
+		{name: "invalid date", expires: "15.06.2023", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validation := &ValidatorData{Expires: tt.expires}
+			if err := checkExpiry(validation, now, tt.gracePeriod); (err != nil) != tt.wantErr {
+				t.Errorf("checkExpiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpiringRegistrations(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	soon := registrationFile{Path: "orgs/soon.yml", Data: ValidatorData{Expires: "2023-06-20"}}
+	today := registrationFile{Path: "orgs/today.yml", Data: ValidatorData{Expires: "2023-06-15"}}
+	later := registrationFile{Path: "orgs/later.yml", Data: ValidatorData{Expires: "2023-12-31"}}
+	expired := registrationFile{Path: "orgs/expired.yml", Data: ValidatorData{Expires: "2023-06-01"}}
+	forever := registrationFile{Path: "orgs/forever.yml"}
+
+	tests := []struct {
+		name          string
+		registrations []registrationFile
+		days          int
+		want          []registrationFile
+	}{
+		{
+			name:          "only registrations inside the reminder window",
+			registrations: []registrationFile{soon, today, later, expired, forever},
+			days:          30,
+			want:          []registrationFile{soon, today},
+		},
+		{
+			name:          "nothing expiring",
+			registrations: []registrationFile{later, forever},
+			days:          30,
+			want:          nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expiringRegistrations(tt.registrations, now, tt.days); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expiringRegistrations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrganizationFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://octodemo.com/orgs-tools", want: "orgs-tools"},
+		{url: "https://octodemo.com/orgs-tools/api", want: "orgs-tools"},
+		{url: "https://other.com/orgs-tools", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := organizationFromURL("https://octodemo.com/", tt.url); got != tt.want {
+				t.Errorf("organizationFromURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_remindRenewals(t *testing.T) {
+	registration := func(days int) string {
+		return testRegistration + fmt.Sprintf("expires: %q\n", time.Now().AddDate(0, 0, days).Format(utils.RegistrationExpiryLayout))
+	}
+	w := newTestWorkflowAction(t, map[string]any{
+		"registration_expiry":  map[string]any{"reminder_days": 30},
+		"registration_sources": map[string]any{"order": []any{utils.RegistrationSourceCentral, utils.RegistrationSourceRepository}},
+	}, nil)
+	w.fake.files(testOrganization, testRepository, "registrations", map[string]string{
+		"expiring.yml": registration(10),
+		"later.yml":    registration(90),
+	})
+	// in-repo registrations are not reminded, even when they are a registration source
+	w.fake.reply(http.MethodGet, "/orgs/mo-octocat/repos", http.StatusOK, []any{map[string]any{"id": 2, "name": "flutter-template"}})
+	w.fake.reply(http.MethodGet, "/repos/mo-octocat/flutter-template/contents/.github/actions-registration.yml", http.StatusOK, map[string]any{
+		"type":     "file",
+		"encoding": "base64",
+		"content":  base64.StdEncoding.EncodeToString([]byte(registration(5))),
+	})
+
+	if err := w.remindRenewals(context.Background()); err != nil {
+		t.Fatalf("remindRenewals() error = %v", err)
+	}
+
+	issues := w.fake.called(http.MethodPost, "/repos/mo-octocat/actions-registry/issues")
+	if len(issues) != 1 {
+		t.Fatalf("remindRenewals() opened %d issues, want 1", len(issues))
+	}
+	if title, _ := issues[0].Body["title"].(string); !strings.Contains(title, "registrations/expiring.yml") {
+		t.Errorf("remindRenewals() opened %q, want a reminder for registrations/expiring.yml", title)
+	}
+	if got := len(w.fake.called(http.MethodGet, "/orgs/mo-octocat/repos")); got != 0 {
+		t.Errorf("remindRenewals() listed the organization repositories %d times, want 0", got)
+	}
+}
@@ -9,12 +9,15 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/go-github/v50/github"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
 
+	"github.tools.sap/actions-rollout-app/config"
 	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/policy"
 	"github.tools.sap/actions-rollout-app/utils"
 )
 
@@ -23,6 +26,7 @@ type RepoActionParams struct {
 	ValidationRepository   string
 	ConfigFileName         string
 	FilesPath              *[]string
+	Event                  *WorkflowActionParams
 }
 
 type RepoAction struct {
@@ -34,6 +38,12 @@ type RepoAction struct {
 	filesPath              *[]string
 	workerPoolSize         float64
 	assignees              *[]string
+	expiryGracePeriod      time.Duration
+	contactVerifier        *contactVerifier
+	contactFailure         string
+	enterpriseURL          string
+	sources                config.RegistrationSourcesConfig
+	policies               *policy.Engine
 }
 
 type ValidatorData struct {
@@ -41,6 +51,37 @@ type ValidatorData struct {
 	ContactEmail string   `yaml:"contactEmail"`
 	UseCase      string   `yaml:"useCase"`
 	Repos        []string `yaml:"repos,omitempty"`
+	Owner        string   `yaml:"owner,omitempty"`
+	Expires      string   `yaml:"expires,omitempty"`
+}
+
+// ExpiresAt returns the expiry date of the registration, ok is false when no expiry is set
+func (v *ValidatorData) ExpiresAt() (expires time.Time, ok bool, err error) {
+	if v.Expires == "" {
+		return time.Time{}, false, nil
+	}
+
+	expires, err = time.Parse(utils.RegistrationExpiryLayout, v.Expires)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%s: %s", utils.ErrInvalidExpiryDate, v.Expires)
+	}
+
+	return expires, true, nil
+}
+
+// checkExpiry fails once a registration is past its expiry date plus the grace period
+func checkExpiry(validation *ValidatorData, now time.Time, gracePeriod time.Duration) error {
+	expires, ok, err := validation.ExpiresAt()
+	if err != nil || !ok {
+		return err
+	}
+
+	// the registration is valid for the whole day it expires on
+	if now.After(expires.AddDate(0, 0, 1).Add(gracePeriod)) {
+		return fmt.Errorf("%s: %s", utils.ErrRegistrationExpired, validation.Expires)
+	}
+
+	return nil
 }
 
 func NewRepoAction(logger *zap.SugaredLogger, client *clients.Github, rawConfig map[string]interface{}) (*RepoAction, error) {
@@ -59,50 +100,65 @@ func NewRepoAction(logger *zap.SugaredLogger, client *clients.Github, rawConfig
 		client:                 client,
 		validationOrganization: validationOrganization,
 		validationRepository:   validationRepository,
+		enterpriseURL:          client.ServerInfo().EnterpriseURL,
 		filesPath:              rawConfig["filesPath"].(*[]string),
 		assignees:              rawConfig["assignees"].(*[]string),
 	}, nil
 }
 
-func (r *RepoAction) HandleRepo(ctx context.Context, params *RepoActionParams) error {
+// HandleRepo validates the repository against all registration files. The returned error is
+// non-nil when no registration applies to the repository and passes validation.
+func (r *RepoAction) HandleRepo(ctx context.Context, params *RepoActionParams) (*ValidationResult, error) {
 	r.logger.Infof("validating repository %s/%s", params.ValidationOrganization, params.ValidationRepository)
-	err := r.handleRepoConfig(ctx, params)
+	result, err := r.handleRepoConfig(ctx, params)
 	if err != nil {
-		return err
+		return result, err
 	}
-	return nil
+	return result, result.Err()
 }
 
-func (r *RepoAction) handleRepoConfig(ctx context.Context, params *RepoActionParams) error {
-	if r.filesPath == nil {
-		return errors.New("no files to validate")
+// handleRepoConfig consults the registration sources in order until one of them validates the repository
+func (r *RepoAction) handleRepoConfig(ctx context.Context, params *RepoActionParams) (*ValidationResult, error) {
+	if params == nil {
+		return nil, errors.New("invalid params")
 	}
 
-	r.logger.Infof("checking paths")
-	if err := r.handleRepoConfigFile(ctx, params); err != nil {
-		return err
+	result := &ValidationResult{
+		Organization: params.ValidationOrganization,
+		Repository:   params.ValidationRepository,
 	}
 
-	return nil
-}
+	validators := map[string]func(context.Context, *RepoActionParams, *ValidationResult) error{
+		utils.RegistrationSourceCentral:    r.handleRepoConfigFile,
+		utils.RegistrationSourceRepository: r.handleRepositoryRegistration,
+	}
 
-func (r *RepoAction) handleRepoConfigFile(ctx context.Context, params *RepoActionParams) error {
-	if params == nil || r.filesPath == nil {
-		return errors.New("invalid params")
+	for _, source := range r.registrationSources() {
+		validate, ok := validators[source]
+		if !ok {
+			return result, fmt.Errorf(utils.ErrUnknownRegistrationSource, source)
+		}
+		if err := validate(ctx, params, result); err != nil {
+			return result, err
+		}
+		if result.Valid() {
+			break
+		}
 	}
 
-	filesCh := make(chan string, len(*r.filesPath))
-	errCh := make(chan error, len(*r.filesPath))
-	isValidCh := make(chan bool, 1)
+	result.sort()
+	return result, nil
+}
 
-	for _, path := range *r.filesPath {
-		filesCh <- path
+func (r *RepoAction) handleRepoConfigFile(ctx context.Context, params *RepoActionParams, result *ValidationResult) error {
+	if r.filesPath == nil {
+		return errors.New("no files to validate")
 	}
 
-	var wg sync.WaitGroup
+	r.logger.Infof("checking paths")
 
-	contentCache := make(map[string][]*github.RepositoryContent)
-	var mu sync.Mutex // Protects access to contentCache
+	var wg sync.WaitGroup
+	var mu sync.Mutex // Protects access to result
 	wg.Add(len(*r.filesPath))
 
 	for _, path := range *r.filesPath {
@@ -110,74 +166,61 @@ func (r *RepoAction) handleRepoConfigFile(ctx context.Context, params *RepoActio
 			defer wg.Done()
 
 			r.logger.Infof("checking  file %s in %s/%s", path, params.ValidationOrganization, params.ValidationRepository)
-			mu.Lock()
-			content, ok := contentCache[r.client.Organization()+"/"+r.client.Repository()+"/"+path]
-			mu.Unlock()
-
-			if !ok {
-				dirContent, err := r.getContents(ctx, path)
-				if err != nil {
-					r.logger.Infof("could not get contents for %s/%s on path %s", params.ValidationOrganization, params.ValidationRepository, path)
-					return
-				}
-				content = dirContent
-
+			content, err := r.getContents(ctx, path)
+			if err != nil {
+				r.logger.Infof("could not get contents for %s/%s on path %s", params.ValidationOrganization, params.ValidationRepository, path)
 				mu.Lock()
-				contentCache[path] = content
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
 				mu.Unlock()
-
+				return
 			}
-			for _, file := range content {
-				r.logger.Infof("checking config file %s on path %s for workflow event from %s/%s", file.GetName(), path, params.ValidationOrganization, params.ValidationRepository)
 
+			for _, file := range content {
 				select {
 				case <-ctx.Done():
 					return
 				default:
 				}
 
-				isValid, err := r.isValidFile(ctx, params, path, file)
-				if isValid {
-					isValidCh <- true
-					return
+				if !r.isFileValid(file) {
+					continue
 				}
-				if err != nil {
+
+				r.logger.Infof("checking config file %s on path %s for workflow event from %s/%s", file.GetName(), path, params.ValidationOrganization, params.ValidationRepository)
+				fileResult := r.validateFile(ctx, params, fmt.Sprintf("%s/%s", path, file.GetName()))
+				fileResult.Source = utils.RegistrationSourceCentral
+				if fileResult.Applies && !fileResult.Valid() {
 					r.logger.Infof("could not validate %s/%s for file %s/%s", params.ValidationOrganization, params.ValidationRepository, path, file.GetName())
-					continue
 				}
-			}
 
+				mu.Lock()
+				result.Files = append(result.Files, fileResult)
+				mu.Unlock()
+			}
 		}(path)
 	}
 
-	go func() {
-		wg.Wait()
-		select {
-		case isValid := <-isValidCh:
-			if !isValid {
-				r.logger.Infof("repository %s/%s is not valid", params.ValidationOrganization, params.ValidationRepository)
-				errCh <- fmt.Errorf("repository %s/%s is not valid", params.ValidationOrganization, params.ValidationRepository)
-				return
-			}
-		default:
-			errCh <- fmt.Errorf("no valid files found in repository %s/%s", params.ValidationOrganization, params.ValidationRepository)
-			return
-		}
-		close(errCh)
-		close(isValidCh)
-		close(filesCh)
-	}()
-
-	for err := range errCh {
-		if err != nil {
-			return err
-		}
+	wg.Wait()
+
+	if !result.Valid() {
+		r.logger.Infof("repository %s/%s is not valid", params.ValidationOrganization, params.ValidationRepository)
 	}
 
 	return nil
 }
 
-func (r *RepoAction) downloadRawData(ctx context.Context, params *RepoActionParams, filePath string) (bool, error) {
+func (r *RepoAction) validateFile(ctx context.Context, params *RepoActionParams, filePath string) FileResult {
+	bytes, err := r.readFile(ctx, filePath)
+	if err != nil {
+		return FileResult{Path: filePath, Failures: []string{err.Error()}}
+	}
+
+	fileResult := r.handleRepoConfigFileContent(ctx, params, bytes)
+	fileResult.Path = filePath
+	return fileResult
+}
+
+func (r *RepoAction) readFile(ctx context.Context, filePath string) ([]byte, error) {
 	rawContents, _, err := r.client.GetV3Client().Repositories.DownloadContents(
 		ctx,
 		r.client.Organization(),
@@ -187,7 +230,7 @@ func (r *RepoAction) downloadRawData(ctx context.Context, params *RepoActionPara
 	)
 	if err != nil {
 		r.logger.Errorw("Error downloading the raw content", "error", err)
-		return false, err
+		return nil, err
 	}
 
 	defer func(rawContents io.ReadCloser) {
@@ -197,65 +240,150 @@ func (r *RepoAction) downloadRawData(ctx context.Context, params *RepoActionPara
 		}
 	}(rawContents)
 
-	bytes, err := io.ReadAll(rawContents)
-	if err != nil {
-		return false, err
-	}
-
-	err = r.handleRepoConfigFileContent(params, bytes)
-	if err != nil {
-		return false, err
-	}
-	return true, nil
+	return io.ReadAll(rawContents)
 }
 
-func (r *RepoAction) handleRepoConfigFileContent(params *RepoActionParams, content []byte) error {
-	// TODO: replace
+// handleRepoConfigFileContent checks a registration against the repository. Applies is set when the
+// registration targets the repository; every rule that does not hold is reported as a failure.
+func (r *RepoAction) handleRepoConfigFileContent(ctx context.Context, params *RepoActionParams, content []byte) FileResult {
+	var fileResult FileResult
 	if content == nil {
-		return errors.New(utils.ErrValidationEmptyContent)
+		fileResult.Failures = append(fileResult.Failures, utils.ErrValidationEmptyContent)
+		return fileResult
 	}
 
 	var validation ValidatorData
 
 	if err := yaml.Unmarshal(content, &validation); err != nil {
-		return err
+		fileResult.Failures = append(fileResult.Failures, err.Error())
+		return fileResult
 	}
 
-	if !(validation.URL == fmt.Sprintf("%s/%s", r.client.ServerInfo().EnterpriseURL, params.ValidationOrganization)) {
-		r.logger.Warnw(utils.ErrInvalidConfigOrganization, "URL", validation.URL, "expected", fmt.Sprintf("%s/%s", r.client.ServerInfo().EnterpriseURL, params.ValidationOrganization))
-		return fmt.Errorf("%s: %s", utils.ErrInvalidConfigOrganization, validation.URL)
+	return r.validateRegistration(ctx, params, &validation)
+}
+
+// validateRegistration checks a parsed registration against the repository
+func (r *RepoAction) validateRegistration(ctx context.Context, params *RepoActionParams, validation *ValidatorData) FileResult {
+	fileResult := r.checkRegistration(ctx, params, validation, true)
+	if fileResult.Valid() {
+		r.logger.Infof("Repository %s/%s is valid", params.ValidationOrganization, params.ValidationRepository)
+	}
+	return fileResult
+}
+
+// checkRegistration checks the rules of a registration, useCase requires the use case to be the organization of the
+// repository. Runner groups map other use cases to groups and check registrations without it.
+func (r *RepoAction) checkRegistration(ctx context.Context, params *RepoActionParams, validation *ValidatorData, useCase bool) FileResult {
+	var fileResult FileResult
+
+	expectedOrganization := fmt.Sprintf("%s/%s", r.enterpriseURL, params.ValidationOrganization)
+	if validation.URL != expectedOrganization {
+		r.logger.Debugw(utils.ErrInvalidConfigOrganization, "URL", validation.URL, "expected", expectedOrganization)
+		return fileResult
+	}
+	expectedRepository := fmt.Sprintf("%s/%s/%s", r.enterpriseURL, params.ValidationOrganization, params.ValidationRepository)
+	if len(validation.Repos) != 0 && !containsString(validation.Repos, expectedRepository) {
+		r.logger.Debugw(utils.ErrInvalidConfigRepository, "Repos", validation.Repos, "expected", expectedRepository)
+		return fileResult
 	}
+	fileResult.Applies = true
+	fileResult.Registration = validation
+
 	if validation.ContactEmail == "" {
 		r.logger.Warnw(utils.ErrInvalidContactEmail, "ContactEmail", validation.ContactEmail)
-		return fmt.Errorf("%s: %s", utils.ErrInvalidContactEmail, validation.ContactEmail)
+		fileResult.Failures = append(fileResult.Failures, utils.ErrInvalidContactEmail)
+	} else if warning, err := r.verifyContact(ctx, params, validation); errors.Is(err, errContactNotMember) {
+		fileResult.Failures = append(fileResult.Failures, err.Error())
+	} else if err != nil {
+		fileResult.Errors = append(fileResult.Errors, err.Error())
+	} else if warning != "" {
+		fileResult.Warnings = append(fileResult.Warnings, warning)
 	}
-	if validation.UseCase != params.ValidationOrganization {
+	if useCase && validation.UseCase != params.ValidationOrganization {
 		r.logger.Warnw(utils.ErrInvalidUseCase, "UseCase", validation.UseCase, "expected", params.ValidationOrganization)
-		return fmt.Errorf("%s: %s", utils.ErrInvalidUseCase, validation.UseCase)
+		fileResult.Failures = append(fileResult.Failures, fmt.Sprintf("%s: %s", utils.ErrInvalidUseCase, validation.UseCase))
 	}
-	if len(validation.Repos) < 0 {
-		r.logger.Warnw(utils.ErrInvalidConfigRepository, "Repos", validation.Repos)
-		return errors.New(utils.ErrInvalidConfigRepository)
+	if err := checkExpiry(validation, time.Now(), r.expiryGracePeriod); err != nil {
+		r.logger.Warnw(utils.ErrRegistrationExpired, "Expires", validation.Expires, "GracePeriod", r.expiryGracePeriod)
+		fileResult.Failures = append(fileResult.Failures, err.Error())
 	}
-	if len(validation.Repos) != 0 {
-		for _, repo := range validation.Repos {
-			if repo != fmt.Sprintf("%s/%s/%s", r.client.ServerInfo().EnterpriseURL, params.ValidationOrganization, params.ValidationRepository) {
-				r.logger.Warnw(utils.ErrInvalidConfigRepository, "Repos", repo, "expected", fmt.Sprintf("%s/%s/%s", r.client.ServerInfo().EnterpriseURL, params.ValidationOrganization, params.ValidationRepository))
-				return fmt.Errorf("%s: %s", utils.ErrInvalidConfigRepository, repo)
-			}
-		}
+
+	if failures, err := r.evaluatePolicies(params, validation); err != nil {
+		fileResult.Errors = append(fileResult.Errors, err.Error())
+	} else {
+		fileResult.Failures = append(fileResult.Failures, failures...)
 	}
-	r.logger.Infof("Repository %s/%s is valid", params.ValidationOrganization, params.ValidationRepository)
 
-	return nil
+	return fileResult
 }
 
-func (r *RepoAction) isValidFile(ctx context.Context, params *RepoActionParams, path string, file *github.RepositoryContent) (bool, error) {
-	if !r.isFileValid(file) {
-		return false, nil
+// verifyContact returns a warning for departed contacts in soft mode and an error in hard mode. Emails that cannot
+// be mapped to a member are always a warning, as that does not prove that the contact has left.
+func (r *RepoAction) verifyContact(ctx context.Context, params *RepoActionParams, validation *ValidatorData) (string, error) {
+	if r.contactVerifier == nil {
+		return "", nil
 	}
 
-	return r.downloadRawData(ctx, params, fmt.Sprintf("%s/%s", path, file.GetName()))
+	err := r.contactVerifier.Verify(ctx, params.ValidationOrganization, validation.ContactEmail)
+	if err == nil {
+		return "", nil
+	}
+
+	r.logger.Warnw(utils.ErrContactNotOrgMember, "ContactEmail", validation.ContactEmail, "organization", params.ValidationOrganization, "error", err)
+	if r.contactFailure != utils.ContactFailureHard || errors.Is(err, errContactUnverified) {
+		return err.Error(), nil
+	}
+	return "", err
+}
+
+// evaluatePolicies runs the configured policy expressions against the event and the matched registration
+func (r *RepoAction) evaluatePolicies(params *RepoActionParams, validation *ValidatorData) ([]string, error) {
+	if r.policies == nil || params.Event == nil {
+		return nil, nil
+	}
+
+	e := params.Event
+	return r.policies.Evaluate(&policy.Input{
+		Event: policy.Event{
+			Name:         string(e.WebhookEvent),
+			Action:       e.Action,
+			Organization: e.Organization,
+			Repository:   e.Repository,
+			WorkflowName: e.WorkflowName,
+			WorkflowID:   e.WorkflowID,
+			WorkflowPath: e.WorkflowPath,
+			RunID:        e.RunID,
+			HeadBranch:   e.HeadBranch,
+			HeadSHA:      e.HeadSHA,
+			Sender:       policy.Sender{Login: e.Sender, Type: e.SenderType},
+		},
+		Registration: policy.Registration{
+			URL:          validation.URL,
+			ContactEmail: validation.ContactEmail,
+			UseCase:      validation.UseCase,
+			Repos:        validation.Repos,
+			Owner:        validation.Owner,
+			Expires:      validation.Expires,
+		},
+		Repo: policy.Repo{
+			Name:          e.Repository,
+			FullName:      e.Repo.FullName,
+			Private:       e.Repo.Private,
+			Visibility:    e.Repo.Visibility,
+			DefaultBranch: e.Repo.DefaultBranch,
+			Archived:      e.Repo.Archived,
+			Fork:          e.Repo.Fork,
+		},
+	})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *RepoAction) isFileValid(file *github.RepositoryContent) bool {
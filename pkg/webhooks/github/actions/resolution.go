@@ -0,0 +1,227 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// openIssue is the persisted record of the open not-valid issues of a workflow. Issue is filed into
+// IssueRepository, the central repository, and RepositoryIssue into the offending repository.
+type openIssue struct {
+	Organization    string    `json:"organization"`
+	Repository      string    `json:"repository"`
+	WorkflowID      int64     `json:"workflow_id"`
+	WorkflowName    string    `json:"workflow_name"`
+	IssueRepository string    `json:"issue_repository,omitempty"`
+	Issue           int       `json:"issue,omitempty"`
+	RepositoryIssue int       `json:"repository_issue,omitempty"`
+	Findings        bool      `json:"findings,omitempty"`
+	OpenedAt        time.Time `json:"opened_at"`
+	// Event is the event the issue was opened on, policies are evaluated against it again
+	Event    *WorkflowActionParams `json:"event,omitempty"`
+	Policies []string              `json:"policies,omitempty"`
+}
+
+// refs returns the issues of the record, records without an issue repository predate configurable targets and
+// refer to the repository of the client
+func (o *openIssue) refs(central string) []issueRef {
+	var refs []issueRef
+	if o.Issue != 0 {
+		repository := o.IssueRepository
+		if repository == "" {
+			repository = central
+		}
+		refs = append(refs, issueRef{Repository: repository, Number: o.Issue})
+	}
+	if o.RepositoryIssue != 0 {
+		refs = append(refs, issueRef{Repository: fmt.Sprintf("%s/%s", o.Organization, o.Repository), Number: o.RepositoryIssue})
+	}
+	return refs
+}
+
+// resolvedComment describes what changed for a repository that validates again
+func resolvedComment(org, repo string, result *ValidationResult) string {
+	var change string
+	if result != nil {
+		for _, file := range result.Files {
+			if file.Valid() {
+				change = fmt.Sprintf(", it is registered in `%s`", file.Path)
+				if file.Registration != nil && file.Registration.UseCase != "" {
+					change += fmt.Sprintf(" for the use case `%s`", file.Registration.UseCase)
+				}
+				break
+			}
+		}
+	}
+
+	comment := fmt.Sprintf(utils.IssueResolvedComment, org, repo, change)
+	if result != nil {
+		comment += result.Markdown()
+	}
+	return comment
+}
+
+// rememberIssue records the issues of a workflow so that they are closed once the repository is valid again
+func (w *WorkflowAction) rememberIssue(p *WorkflowActionParams, central string, issue, repositoryIssue int, result *ValidationResult) {
+	key := w.workflowKey(p.Organization, p.Repository, p.WorkflowID)
+	record := &openIssue{
+		Organization:    p.Organization,
+		Repository:      p.Repository,
+		WorkflowID:      p.WorkflowID,
+		WorkflowName:    p.WorkflowName,
+		IssueRepository: central,
+		Issue:           issue,
+		RepositoryIssue: repositoryIssue,
+		Findings:        result != nil && len(result.Findings) > 0,
+		OpenedAt:        time.Now().UTC(),
+	}
+	if result != nil {
+		record.Policies = result.Policies()
+		event := *p
+		record.Event = &event
+	}
+	if err := w.state.Put(utils.StateBucketOpenIssues, key, record); err != nil {
+		w.logger.Errorw("error recording open issue", "key", key, "error", err)
+	}
+}
+
+// resolveWorkflowIssue closes the open issue of a workflow that validated successfully
+func (w *WorkflowAction) resolveWorkflowIssue(ctx context.Context, p *WorkflowActionParams, result *ValidationResult) error {
+	record := &openIssue{}
+	found, err := w.state.Get(utils.StateBucketOpenIssues, w.workflowKey(p.Organization, p.Repository, p.WorkflowID), record)
+	if err != nil || !found {
+		return err
+	}
+	return w.resolveIssue(ctx, record, result)
+}
+
+func (w *WorkflowAction) resolveIssue(ctx context.Context, record *openIssue, result *ValidationResult) error {
+	for _, ref := range record.refs(w.centralRepository()) {
+		r, err := w.issueRepository(ref.Repository)
+		if err != nil {
+			return err
+		}
+		issue, resp, err := r.client.GetV3Client().Issues.Get(ctx, r.owner, r.name, ref.Number)
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return err
+		}
+		if err != nil || issue.GetState() != "open" {
+			continue
+		}
+
+		if err := w.closeWorkflowIssue(ctx, r, ref.Number, resolvedComment(record.Organization, record.Repository, result)); err != nil {
+			return err
+		}
+		w.logger.Infow("issue closed", "organization", record.Organization, "repository", record.Repository, "issue_repository", r.String(), "issue", ref.Number)
+		w.recordAudit(&WorkflowActionParams{
+			WorkflowName: record.WorkflowName,
+			WorkflowID:   record.WorkflowID,
+			Organization: record.Organization,
+			Repository:   record.Repository,
+		}, utils.AuditActionIssueClosed, result)
+	}
+
+	return w.state.Delete(utils.StateBucketOpenIssues, w.workflowKey(record.Organization, record.Repository, record.WorkflowID))
+}
+
+// resolveIssues validates the repositories of all open issues again and closes the issues of the valid ones.
+// Policies are evaluated against the event the issue was opened on. Issues that were opened for workflow analysis
+// findings, or for policies without a recorded event, are left to the next workflow run.
+func (w *WorkflowAction) resolveIssues(ctx context.Context) error {
+	prefix := fmt.Sprintf("%s/%s:", w.organization, w.repository)
+	repos := make(map[string][]*openIssue)
+	for _, key := range w.state.Keys(utils.StateBucketOpenIssues) {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		record := &openIssue{}
+		if _, err := w.state.Get(utils.StateBucketOpenIssues, key, record); err != nil {
+			return err
+		}
+		if record.Findings || (len(record.Policies) > 0 && record.Event == nil) {
+			continue
+		}
+		repo := fmt.Sprintf("%s/%s", record.Organization, record.Repository)
+		repos[repo] = append(repos[repo], record)
+	}
+
+	for _, records := range repos {
+		org, repo := records[0].Organization, records[0].Repository
+		var repoResult *ValidationResult
+		var repoErr error
+
+		for _, record := range records {
+			result, err := repoResult, repoErr
+			if record.Event != nil {
+				result, err = w.repoAction().HandleRepo(ctx, &RepoActionParams{
+					ValidationOrganization: org,
+					ValidationRepository:   repo,
+					Event:                  record.Event,
+				})
+			} else if repoResult == nil {
+				repoResult, repoErr = w.repoAction().HandleRepo(ctx, &RepoActionParams{
+					ValidationOrganization: org,
+					ValidationRepository:   repo,
+				})
+				result, err = repoResult, repoErr
+			}
+			if err != nil {
+				w.logger.Debugw("repository still not valid", "organization", org, "repository", repo, "workflow_id", record.WorkflowID, "error", err)
+				continue
+			}
+
+			if err := w.resolveIssue(ctx, record, result); err != nil {
+				w.logger.Errorw("error closing issue", "organization", org, "repository", repo, "issue", record.Issue, "error", err)
+			}
+			if err := w.resolveCheckRun(ctx, org, repo, record.WorkflowID); err != nil {
+				w.logger.Errorw("error resolving check run", "organization", org, "repository", repo, "workflow_id", record.WorkflowID, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// closeWorkflowIssue comments on the issue, closes it and replaces the not-valid label
+func (w *WorkflowAction) closeWorkflowIssue(ctx context.Context, r *issueRepository, number int, comment string) error {
+	issues := r.client.GetV3Client().Issues
+	if _, _, err := issues.CreateComment(ctx, r.owner, r.name, number, &github.IssueComment{
+		Body: github.String(comment),
+	}); err != nil {
+		return err
+	}
+
+	if _, _, err := issues.Edit(ctx, r.owner, r.name, number, &github.IssueRequest{
+		State:       github.String("closed"),
+		StateReason: github.String("completed"),
+	}); err != nil {
+		return err
+	}
+
+	resp, err := issues.RemoveLabelForIssue(ctx, r.owner, r.name, number, utils.LabelNotValid)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return err
+	}
+	labels, err := w.ensureLabels(ctx, r, []config.IssueLabel{{Name: w.issueResolution.Label, Color: utils.LabelResolvedColor}})
+	if err != nil {
+		return err
+	}
+	_, _, err = issues.AddLabelsToIssue(ctx, r.owner, r.name, number, labels)
+	return err
+}
+
+func (w *WorkflowAction) issueResolutionJob() scheduler.Job {
+	return scheduler.Job{
+		Name:     fmt.Sprintf("issue-resolution-%s/%s", w.organization, w.repository),
+		Interval: w.issueResolution.Interval,
+		Run:      w.resolveIssues,
+	}
+}
@@ -0,0 +1,123 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/pkg/analysis"
+	"github.tools.sap/actions-rollout-app/pkg/state"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestResolvedComment(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *ValidationResult
+		want   string
+	}{
+		{
+			name: "registration",
+			result: &ValidationResult{Files: []FileResult{
+				{Path: "registrations/other.yml"},
+				{Path: "registrations/mo-octocat.yml", Applies: true, Registration: &ValidatorData{UseCase: "mo-octocat"}},
+			}},
+			want: ":white_check_mark: mo-octocat/flutter-template is valid again, it is registered in `registrations/mo-octocat.yml` for the use case `mo-octocat`, closing this issue.",
+		},
+		{
+			name: "no result",
+			want: ":white_check_mark: mo-octocat/flutter-template is valid again, closing this issue.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolvedComment("mo-octocat", "flutter-template", tt.result)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("resolvedComment() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_rememberIssue(t *testing.T) {
+	s, err := state.New(zap.NewNop().Sugar(), nil)
+	if err != nil {
+		t.Fatalf("state.New() error = %v", err)
+	}
+	w := &WorkflowAction{logger: zap.NewNop().Sugar(), organization: "mo-octocat", repository: "actions-control", state: s}
+
+	p := &WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template", WorkflowID: 42, WorkflowName: "build"}
+	w.rememberIssue(p, "mo-octocat/actions-control", 7, 0, &ValidationResult{})
+
+	record := &openIssue{}
+	found, err := s.Get(utils.StateBucketOpenIssues, w.workflowKey(p.Organization, p.Repository, p.WorkflowID), record)
+	if err != nil || !found {
+		t.Fatalf("open issue not recorded, found = %v, error = %v", found, err)
+	}
+	if record.Issue != 7 || record.IssueRepository != "mo-octocat/actions-control" || record.WorkflowName != "build" || record.Findings {
+		t.Errorf("rememberIssue() recorded %+v", record)
+	}
+
+	// workflows without an open issue are left alone without calling GitHub
+	other := &WorkflowActionParams{Organization: "mo-octocat", Repository: "flutter-template", WorkflowID: 43}
+	if err := w.resolveWorkflowIssue(context.Background(), other, nil); err != nil {
+		t.Errorf("resolveWorkflowIssue() error = %v", err)
+	}
+}
+
+func TestWorkflowAction_resolveIssues(t *testing.T) {
+	w := newTestWorkflowAction(t, map[string]any{
+		"policies": []any{map[string]any{"name": "main-only", "expression": `event.head_branch == "main"`}},
+	}, nil)
+	w.fake.files(testOrganization, testRepository, "registrations", map[string]string{"flutter.yml": testRegistration})
+
+	event := func(workflowID int64, branch string) *WorkflowActionParams {
+		return &WorkflowActionParams{Organization: testOrganization, Repository: "flutter-template", WorkflowID: workflowID, WorkflowName: "build", HeadBranch: branch}
+	}
+	central := testOrganization + "/" + testRepository
+	policyResult := &ValidationResult{Files: []FileResult{{Applies: true, Failures: []string{"policy main-only: branch is not main"}}}}
+	w.rememberIssue(event(1, "dev"), central, 1, 0, policyResult)
+	w.rememberIssue(event(2, "main"), central, 2, 0, policyResult)
+	w.rememberIssue(event(3, "main"), central, 3, 0, &ValidationResult{Findings: []analysis.Finding{{Rule: "unpinned-action"}}})
+	w.rememberIssue(event(4, "main"), central, 4, 0, policyResult)
+	record := &openIssue{}
+	key := w.workflowKey(testOrganization, "flutter-template", 4)
+	if _, err := w.state.Get(utils.StateBucketOpenIssues, key, record); err != nil {
+		t.Fatal(err)
+	}
+	record.Event = nil
+	if err := w.state.Put(utils.StateBucketOpenIssues, key, record); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.resolveIssues(context.Background()); err != nil {
+		t.Fatalf("resolveIssues() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		workflowID   int64
+		wantResolved bool
+	}{
+		{name: "policy still violated by the recorded event", workflowID: 1},
+		{name: "policy satisfied by the recorded event", workflowID: 2, wantResolved: true},
+		{name: "analysis findings", workflowID: 3},
+		{name: "policy without recorded event", workflowID: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := w.state.Get(utils.StateBucketOpenIssues, w.workflowKey(testOrganization, "flutter-template", tt.workflowID), &openIssue{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checked := len(w.fake.called(http.MethodGet, fmt.Sprintf("/repos/mo-octocat/actions-registry/issues/%d", tt.workflowID))) > 0
+			if resolved := !found; resolved != tt.wantResolved || checked != tt.wantResolved {
+				t.Errorf("issue of workflow %d resolved = %v, checked = %v, want %v", tt.workflowID, resolved, checked, tt.wantResolved)
+			}
+		})
+	}
+}
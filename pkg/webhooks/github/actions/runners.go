@@ -0,0 +1,294 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// runnerGroupChange is recorded in the audit log for every repository added to or removed from a runner group
+type runnerGroupChange struct {
+	Group string `json:"group"`
+}
+
+// repositoryFromURL splits a repository URL of the enterprise into organization and repository
+func repositoryFromURL(enterpriseURL, url string) (string, string, bool) {
+	path := strings.TrimPrefix(url, strings.TrimSuffix(enterpriseURL, "/")+"/")
+	if path == url {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// runnerGroupAccess computes the repositories per organization and runner group from the registrations.
+// Only repositories listed in a registration are granted, valid decides whether the registration holds
+// for the repository. An error of valid aborts the computation, as the access would be incomplete. Every
+// group a registration maps to is part of the access of its organizations, even without valid repositories.
+func runnerGroupAccess(enterpriseURL string, registrations []registrationFile, groups map[string]string, valid func(registrationFile, string, string) (bool, error)) (map[string]map[string][]string, error) {
+	access := make(map[string]map[string]map[string]bool)
+	for _, registration := range registrations {
+		group, ok := groups[registration.Data.UseCase]
+		if !ok {
+			continue
+		}
+		for _, url := range registration.Data.Repos {
+			org, repo, ok := repositoryFromURL(enterpriseURL, url)
+			if !ok {
+				continue
+			}
+			if access[org] == nil {
+				access[org] = make(map[string]map[string]bool)
+			}
+			if access[org][group] == nil {
+				access[org][group] = make(map[string]bool)
+			}
+			ok, err := valid(registration, org, repo)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				access[org][group][repo] = true
+			}
+		}
+	}
+
+	result := make(map[string]map[string][]string)
+	for org, orgGroups := range access {
+		result[org] = make(map[string][]string)
+		for group, repos := range orgGroups {
+			result[org][group] = make([]string, 0, len(repos))
+			for repo := range repos {
+				result[org][group] = append(result[org][group], repo)
+			}
+			sort.Strings(result[org][group])
+		}
+	}
+	return result, nil
+}
+
+// diffRepositories returns the repositories missing from current and the ones that are no longer desired
+func diffRepositories(current, desired []string) (added, removed []string) {
+	currentSet := make(map[string]bool)
+	for _, repo := range current {
+		currentSet[repo] = true
+	}
+	desiredSet := make(map[string]bool)
+	for _, repo := range desired {
+		desiredSet[repo] = true
+		if !currentSet[repo] {
+			added = append(added, repo)
+		}
+	}
+	for _, repo := range current {
+		if !desiredSet[repo] {
+			removed = append(removed, repo)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// syncRunnerGroups reconciles the repository access of the managed runner groups with the valid registrations of
+// the registration sources. Nothing is synced when a registration cannot be read or validated, a partial view would
+// remove repositories.
+func (w *WorkflowAction) syncRunnerGroups(ctx context.Context) error {
+	repoAction := w.repoAction()
+	registrations, err := repoAction.sourceRegistrations(ctx, w.runnerGroups.Organizations, true)
+	if err != nil {
+		return err
+	}
+
+	access, err := runnerGroupAccess(w.client.ServerInfo().EnterpriseURL, registrations, w.runnerGroups.Groups, func(registration registrationFile, org, repo string) (bool, error) {
+		params := &RepoActionParams{ValidationOrganization: org, ValidationRepository: repo}
+		// the use case selects the runner group, it does not have to be the organization
+		result := repoAction.checkRegistration(ctx, params, &registration.Data, false)
+		if registration.Source == utils.RegistrationSourceRepository {
+			repoAction.checkCountersignature(ctx, params, &result)
+		}
+		if len(result.Errors) > 0 {
+			return false, fmt.Errorf(utils.ErrRunnerGroupValidation, org, repo, strings.Join(result.Errors, ", "))
+		}
+		return result.Applies && result.Valid(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, org := range w.runnerGroups.Organizations {
+		if err := w.syncOrganizationRunnerGroups(ctx, org, access[org]); err != nil {
+			w.logger.Errorw("error syncing runner groups", "organization", org, "error", err)
+		}
+	}
+	return nil
+}
+
+func (w *WorkflowAction) syncOrganizationRunnerGroups(ctx context.Context, org string, desired map[string][]string) error {
+	orgClient, err := w.client.ForOrganization(org)
+	if err != nil {
+		return err
+	}
+
+	groups, err := listRunnerGroups(ctx, orgClient, org)
+	if err != nil {
+		return err
+	}
+
+	managed := make(map[string]bool)
+	for _, name := range w.runnerGroups.Groups {
+		managed[name] = true
+	}
+
+	names := make([]string, 0, len(managed))
+	for name := range managed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		group, ok := groups[name]
+		if !ok {
+			w.logger.Warnw("runner group not found", "organization", org, "group", name)
+			continue
+		}
+		if group.GetVisibility() != utils.RunnerGroupVisibilitySelected {
+			w.logger.Warnw("runner group is not restricted to selected repositories", "organization", org, "group", name, "visibility", group.GetVisibility())
+			continue
+		}
+		repos, ok := desired[name]
+		if !ok {
+			// an unmapped use case or a registration source that cannot be read must not empty the group
+			w.logger.Warnw("no registration maps to the runner group, not synced", "organization", org, "group", name)
+			continue
+		}
+		if err := w.syncRunnerGroup(ctx, orgClient, org, group, repos); err != nil {
+			w.logger.Errorw("error syncing runner group", "organization", org, "group", name, "error", err)
+		}
+	}
+	return nil
+}
+
+// syncRunnerGroup applies the difference between the repositories of the group and the desired ones. In audit
+// mode the changes are only recorded, removals are counted by the circuit breaker.
+func (w *WorkflowAction) syncRunnerGroup(ctx context.Context, orgClient *clients.Github, org string, group *github.RunnerGroup, desired []string) error {
+	current, err := listRunnerGroupRepositories(ctx, orgClient, org, group.GetID())
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	added, removed := diffRepositories(names, desired)
+
+	if mode := w.enforcementMode(org); mode == utils.EnforcementModeAudit {
+		for _, repo := range added {
+			w.recordRunnerGroupChange(org, repo, group.GetName(), utils.AuditActionRunnerGroupWouldAdd, mode)
+		}
+		for _, repo := range removed {
+			w.recordRunnerGroupChange(org, repo, group.GetName(), utils.AuditActionRunnerGroupWouldRemove, mode)
+		}
+		if len(added) > 0 || len(removed) > 0 {
+			w.logger.Infow("audit mode, runner group not synced", "organization", org, "group", group.GetName(), "added", added, "removed", removed)
+		}
+		return nil
+	}
+
+	actions := orgClient.GetV3Client().Actions
+	for _, repo := range added {
+		repository, _, err := orgClient.GetV3Client().Repositories.Get(ctx, org, repo)
+		if err != nil {
+			w.logger.Errorw("error reading repository", "organization", org, "repository", repo, "error", err)
+			continue
+		}
+		if _, err := actions.AddRepositoryAccessRunnerGroup(ctx, org, group.GetID(), repository.GetID()); err != nil {
+			return err
+		}
+		w.logger.Infow("repository added to runner group", "organization", org, "repository", repo, "group", group.GetName())
+		w.recordRunnerGroupChange(org, repo, group.GetName(), utils.AuditActionRunnerGroupAdded, utils.EnforcementModeEnforce)
+	}
+	for _, repo := range removed {
+		if !w.breaker.Allow(ctx, org) {
+			w.logger.Warnw("circuit breaker open, repository not removed from runner group", "organization", org, "repository", repo, "group", group.GetName())
+			w.recordRunnerGroupChange(org, repo, group.GetName(), utils.AuditActionRunnerGroupWouldRemove, utils.EnforcementModeAudit)
+			continue
+		}
+		if _, err := actions.RemoveRepositoryAccessRunnerGroup(ctx, org, group.GetID(), current[repo]); err != nil {
+			return err
+		}
+		w.logger.Infow("repository removed from runner group", "organization", org, "repository", repo, "group", group.GetName())
+		w.recordRunnerGroupChange(org, repo, group.GetName(), utils.AuditActionRunnerGroupRemoved, utils.EnforcementModeEnforce)
+	}
+	return nil
+}
+
+func (w *WorkflowAction) recordRunnerGroupChange(org, repo, group, action, mode string) {
+	err := w.audit.Record(audit.Entry{
+		Organization: org,
+		Repository:   repo,
+		Action:       action,
+		Mode:         mode,
+		Result:       &runnerGroupChange{Group: group},
+	})
+	if err != nil {
+		w.logger.Errorw(utils.LoggerErrorRecordingAudit, "error", err)
+	}
+}
+
+func listRunnerGroups(ctx context.Context, orgClient *clients.Github, org string) (map[string]*github.RunnerGroup, error) {
+	groups := make(map[string]*github.RunnerGroup)
+	opts := &github.ListOrgRunnerGroupOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := orgClient.GetV3Client().Actions.ListOrganizationRunnerGroups(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range page.RunnerGroups {
+			groups[group.GetName()] = group
+		}
+		if resp.NextPage == 0 {
+			return groups, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listRunnerGroupRepositories returns the repository IDs by name of a runner group
+func listRunnerGroupRepositories(ctx context.Context, orgClient *clients.Github, org string, groupID int64) (map[string]int64, error) {
+	repos := make(map[string]int64)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := orgClient.GetV3Client().Actions.ListRepositoryAccessRunnerGroup(ctx, org, groupID, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range page.Repositories {
+			repos[repo.GetName()] = repo.GetID()
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (w *WorkflowAction) runnerGroupsJob() scheduler.Job {
+	return scheduler.Job{
+		Name:     fmt.Sprintf("runner-groups-%s/%s", w.organization, w.repository),
+		Interval: w.runnerGroups.Interval,
+		Run:      w.syncRunnerGroups,
+	}
+}
@@ -0,0 +1,174 @@
+package actions
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestRunnerGroupAccess(t *testing.T) {
+	enterpriseURL := "https://octodemo.com"
+	registrations := []registrationFile{
+		{Path: "orgs-tools/build.yml", Data: ValidatorData{UseCase: "build", Repos: []string{
+			"https://octodemo.com/orgs-tools/api",
+			"https://octodemo.com/orgs-tools/web",
+			"https://octodemo.com/orgs-wdf/ui",
+		}}},
+		{Path: "orgs-tools/expired.yml", Data: ValidatorData{UseCase: "build", Expires: "2020-01-01", Repos: []string{
+			"https://octodemo.com/orgs-tools/legacy",
+		}}},
+		{Path: "orgs-tools/release.yml", Data: ValidatorData{UseCase: "release", Repos: []string{
+			"https://octodemo.com/orgs-tools/api",
+			"https://other.com/orgs-tools/cli",
+		}}},
+		{Path: "orgs-tools/docs.yml", Data: ValidatorData{UseCase: "docs", Repos: []string{
+			"https://octodemo.com/orgs-tools/docs",
+		}}},
+		{Path: "orgs-wdf/release.yml", Data: ValidatorData{UseCase: "release", Expires: "2020-01-01", Repos: []string{
+			"https://octodemo.com/orgs-wdf/ui",
+		}}},
+	}
+	groups := map[string]string{"build": "build-runners", "release": "release-runners"}
+	valid := func(registration registrationFile, org, repo string) (bool, error) {
+		return registration.Data.Expires == "", nil
+	}
+
+	want := map[string]map[string][]string{
+		"orgs-tools": {
+			"build-runners":   {"api", "web"},
+			"release-runners": {"api"},
+		},
+		"orgs-wdf": {
+			"build-runners":   {"ui"},
+			"release-runners": {},
+		},
+	}
+	got, err := runnerGroupAccess(enterpriseURL, registrations, groups, valid)
+	if err != nil {
+		t.Fatalf("runnerGroupAccess() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("runnerGroupAccess() = %v, want %v", got, want)
+	}
+
+	failing := func(registration registrationFile, org, repo string) (bool, error) {
+		return false, errors.New("lookup failed")
+	}
+	if _, err := runnerGroupAccess(enterpriseURL, registrations, groups, failing); err == nil {
+		t.Error("runnerGroupAccess() error = nil, want the validation error")
+	}
+}
+
+func TestDiffRepositories(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     []string
+		desired     []string
+		wantAdded   []string
+		wantRemoved []string
+	}{
+		{name: "in sync", current: []string{"api", "web"}, desired: []string{"web", "api"}},
+		{name: "added", current: []string{"api"}, desired: []string{"api", "web"}, wantAdded: []string{"web"}},
+		{name: "removed", current: []string{"api", "legacy"}, desired: []string{"api"}, wantRemoved: []string{"legacy"}},
+		{name: "empty group", desired: []string{"api"}, wantAdded: []string{"api"}},
+		{name: "no registrations left", current: []string{"api"}, wantRemoved: []string{"api"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := diffRepositories(tt.current, tt.desired)
+			if !reflect.DeepEqual(added, tt.wantAdded) || !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("diffRepositories() = %v, %v, want %v, %v", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+// buildRegistration registers mo-octocat/flutter-template for the build use case, which runner groups map to a group
+const buildRegistration = `
+url: https://octodemo.com/mo-octocat
+contactEmail: octocat
+useCase: build
+repos:
+  - https://octodemo.com/mo-octocat/flutter-template
+`
+
+func TestWorkflowAction_syncRunnerGroups(t *testing.T) {
+	const group = "/orgs/mo-octocat/actions/runner-groups/7/repositories"
+	expired := buildRegistration + "expires: \"2020-01-01\"\n"
+	tests := []struct {
+		name          string
+		rawConfig     map[string]any
+		breaker       *config.CircuitBreaker
+		files         map[string]string
+		inRepo        string
+		wantErr       bool
+		wantAdded     int
+		wantRemoved   int
+		wantAuditOnly bool
+	}{
+		{name: "enforce", files: map[string]string{"build.yml": buildRegistration}, wantAdded: 1, wantRemoved: 2},
+		{name: "audit mode", rawConfig: map[string]any{"enforcement": map[string]any{"mode": utils.EnforcementModeAudit}}, files: map[string]string{"build.yml": buildRegistration}, wantAuditOnly: true},
+		{name: "breaker limits removals", breaker: &config.CircuitBreaker{OrganizationThreshold: 1}, files: map[string]string{"build.yml": buildRegistration}, wantAdded: 1, wantRemoved: 1},
+		{name: "contact lookup failing", rawConfig: map[string]any{"contact_verification": map[string]any{"enabled": true, "failure": utils.ContactFailureHard}}, files: map[string]string{"build.yml": buildRegistration}, wantErr: true},
+		{name: "unparsable registration", files: map[string]string{"build.yml": buildRegistration, "broken.yml": "url: ["}, wantErr: true},
+		{name: "expired registration", files: map[string]string{"build.yml": expired}, wantRemoved: 2},
+		{name: "in-repo registration", rawConfig: map[string]any{"registration_sources": map[string]any{"order": []any{utils.RegistrationSourceCentral, utils.RegistrationSourceRepository}}}, files: map[string]string{}, inRepo: buildRegistration, wantAdded: 1, wantRemoved: 2},
+		{name: "no registration maps to the group", files: map[string]string{"flutter.yml": testRegistration}},
+		{name: "no registrations left", files: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawConfig := map[string]any{"runner_groups": map[string]any{
+				"organizations": []any{testOrganization},
+				"groups":        map[string]any{"build": "build-runners"},
+			}}
+			for k, v := range tt.rawConfig {
+				rawConfig[k] = v
+			}
+			w := newTestWorkflowAction(t, rawConfig, tt.breaker)
+			w.fake.files(testOrganization, testRepository, "registrations", tt.files)
+			w.fake.reply(http.MethodGet, "/orgs/mo-octocat/repos", http.StatusOK, []any{map[string]any{"id": 2, "name": "flutter-template"}})
+			if tt.inRepo != "" {
+				w.fake.reply(http.MethodGet, "/repos/mo-octocat/flutter-template/contents/.github/actions-registration.yml", http.StatusOK, map[string]any{
+					"type":     "file",
+					"encoding": "base64",
+					"content":  base64.StdEncoding.EncodeToString([]byte(tt.inRepo)),
+				})
+			}
+			w.fake.reply(http.MethodGet, "/orgs/mo-octocat/actions/runner-groups", http.StatusOK, map[string]any{
+				"total_count":   1,
+				"runner_groups": []any{map[string]any{"id": 7, "name": "build-runners", "visibility": utils.RunnerGroupVisibilitySelected}},
+			})
+			w.fake.reply(http.MethodGet, group, http.StatusOK, map[string]any{
+				"total_count":  2,
+				"repositories": []any{map[string]any{"id": 1, "name": "legacy"}, map[string]any{"id": 3, "name": "old"}},
+			})
+			w.fake.reply(http.MethodGet, "/repos/mo-octocat/flutter-template", http.StatusOK, map[string]any{"id": 2, "name": "flutter-template"})
+			for _, path := range []string{group + "/1", group + "/2", group + "/3"} {
+				w.fake.reply(http.MethodPut, path, http.StatusNoContent, nil)
+				w.fake.reply(http.MethodDelete, path, http.StatusNoContent, nil)
+			}
+
+			err := w.syncRunnerGroups(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("syncRunnerGroups() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			added := len(w.fake.called(http.MethodPut, group+"/2"))
+			removed := len(w.fake.called(http.MethodDelete, group+"/1")) + len(w.fake.called(http.MethodDelete, group+"/3"))
+			if added != tt.wantAdded || removed != tt.wantRemoved {
+				t.Errorf("added = %d, removed = %d, want %d and %d", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+			actions := w.actions(t)
+			if tt.wantAuditOnly && (actions[utils.AuditActionRunnerGroupWouldAdd] != 1 || actions[utils.AuditActionRunnerGroupWouldRemove] != 2) {
+				t.Errorf("audit entries = %v, want one would-add and two would-remove", actions)
+			}
+		})
+	}
+}
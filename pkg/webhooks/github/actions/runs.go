@@ -0,0 +1,86 @@
+package actions
+
+import (
+	"sync"
+	"time"
+)
+
+// runTracker remembers the workflow runs that have been handled. GitHub delivers a workflow_run event when a run is
+// requested, in progress and completed; only the first delivery of a run is enforced.
+type runTracker struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu   sync.Mutex
+	seen map[int64]time.Time
+}
+
+func newRunTracker(ttl time.Duration) *runTracker {
+	return &runTracker{ttl: ttl, now: time.Now, seen: make(map[int64]time.Time)}
+}
+
+// first marks the run as handled and reports whether it was not handled before, events without a run are always first
+func (t *runTracker) first(runID int64) bool {
+	if runID == 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for id, seen := range t.seen {
+		if now.Sub(seen) >= t.ttl {
+			delete(t.seen, id)
+		}
+	}
+	if _, ok := t.seen[runID]; ok {
+		return false
+	}
+	t.seen[runID] = now
+	return true
+}
+
+// forget lets the next delivery of the run be handled again, used when handling it failed
+func (t *runTracker) forget(runID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.seen, runID)
+}
+
+// keyedMutex serializes read-modify-write cycles on state store entries with the same key
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// Lock locks the key and returns the function that unlocks it
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
@@ -0,0 +1,199 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v2"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// newRegistrationSources applies the defaults and checks the configured source order
+func newRegistrationSources(sources config.RegistrationSourcesConfig) (config.RegistrationSourcesConfig, error) {
+	if len(sources.Order) == 0 {
+		sources.Order = []string{utils.RegistrationSourceCentral}
+	}
+	for _, source := range sources.Order {
+		if source != utils.RegistrationSourceCentral && source != utils.RegistrationSourceRepository {
+			return sources, fmt.Errorf(utils.ErrUnknownRegistrationSource, source)
+		}
+	}
+	if sources.RepositoryPath == "" {
+		sources.RepositoryPath = utils.DefaultRepositoryRegistrationPath
+	}
+	if sources.CountersignaturePath == "" {
+		sources.CountersignaturePath = utils.DefaultCountersignaturePath
+	}
+	return sources, nil
+}
+
+func (r *RepoAction) registrationSources() []string {
+	if len(r.sources.Order) == 0 {
+		return []string{utils.RegistrationSourceCentral}
+	}
+	return r.sources.Order
+}
+
+// handleRepositoryRegistration validates the registration file carried by the repository itself
+func (r *RepoAction) handleRepositoryRegistration(ctx context.Context, params *RepoActionParams, result *ValidationResult) error {
+	content, found, err := r.readRepositoryFile(ctx, params, r.sources.RepositoryPath)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s/%s/%s: %v", params.ValidationOrganization, params.ValidationRepository, r.sources.RepositoryPath, err))
+		return nil
+	}
+	if !found {
+		r.logger.Debugw("no in-repo registration found", "organization", params.ValidationOrganization, "repository", params.ValidationRepository)
+		return nil
+	}
+
+	fileResult := r.handleRepoConfigFileContent(ctx, params, content)
+	fileResult.Path = fmt.Sprintf("%s/%s/%s", params.ValidationOrganization, params.ValidationRepository, r.sources.RepositoryPath)
+	fileResult.Source = utils.RegistrationSourceRepository
+
+	r.checkCountersignature(ctx, params, &fileResult)
+
+	result.Files = append(result.Files, fileResult)
+	return nil
+}
+
+// checkCountersignature fails in-repo registrations that are not countersigned, when countersignatures are required
+func (r *RepoAction) checkCountersignature(ctx context.Context, params *RepoActionParams, fileResult *FileResult) {
+	if !fileResult.Applies || !r.sources.RequireCountersignature {
+		return
+	}
+	countersigned, err := r.isCountersigned(ctx, params)
+	if err != nil {
+		fileResult.Failures = append(fileResult.Failures, err.Error())
+	} else if !countersigned {
+		fileResult.Failures = append(fileResult.Failures, utils.ErrMissingCountersignature)
+	}
+}
+
+// sourceRegistrations reads the registrations of the configured sources: the central files and the in-repo
+// registrations of the repositories of orgs
+func (r *RepoAction) sourceRegistrations(ctx context.Context, orgs []string, strict bool) ([]registrationFile, error) {
+	var registrations []registrationFile
+	for _, source := range r.registrationSources() {
+		switch source {
+		case utils.RegistrationSourceCentral:
+			central, err := r.readRegistrations(ctx, strict)
+			if err != nil {
+				return nil, err
+			}
+			registrations = append(registrations, central...)
+		case utils.RegistrationSourceRepository:
+			for _, org := range orgs {
+				inRepo, err := r.readRepositoryRegistrations(ctx, org, strict)
+				if err != nil {
+					return nil, err
+				}
+				registrations = append(registrations, inRepo...)
+			}
+		}
+	}
+	return registrations, nil
+}
+
+// readRepositoryRegistrations reads the in-repo registrations of the repositories of the organization. An in-repo
+// registration only registers its own repository.
+func (r *RepoAction) readRepositoryRegistrations(ctx context.Context, org string, strict bool) ([]registrationFile, error) {
+	orgClient, err := r.client.ForOrganization(org)
+	if err != nil {
+		return nil, err
+	}
+
+	var registrations []registrationFile
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		repos, resp, err := orgClient.GetV3Client().Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			if repo.GetArchived() {
+				continue
+			}
+			params := &RepoActionParams{ValidationOrganization: org, ValidationRepository: repo.GetName()}
+			content, found, err := r.readRepositoryFile(ctx, params, r.sources.RepositoryPath)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+
+			filePath := fmt.Sprintf("%s/%s/%s", org, repo.GetName(), r.sources.RepositoryPath)
+			var data ValidatorData
+			if err := yaml.Unmarshal(content, &data); err != nil {
+				if strict {
+					return nil, fmt.Errorf(utils.ErrRegistrationUnparsable, filePath, err)
+				}
+				r.logger.Warnw("skipping unparsable registration", "file", filePath, "error", err)
+				continue
+			}
+			repositoryURL := fmt.Sprintf("%s/%s/%s", r.enterpriseURL, org, repo.GetName())
+			if len(data.Repos) != 0 && !containsString(data.Repos, repositoryURL) {
+				continue
+			}
+			data.Repos = []string{repositoryURL}
+			registrations = append(registrations, registrationFile{Path: filePath, Source: utils.RegistrationSourceRepository, Data: data})
+		}
+		if resp.NextPage == 0 {
+			return registrations, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (r *RepoAction) readRepositoryFile(ctx context.Context, params *RepoActionParams, path string) ([]byte, bool, error) {
+	repoClient, err := r.client.ForRepository(params.ValidationOrganization, params.ValidationRepository)
+	if err != nil {
+		return nil, false, err
+	}
+
+	file, _, resp, err := repoClient.GetV3Client().Repositories.GetContents(ctx, params.ValidationOrganization, params.ValidationRepository, path, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if file == nil {
+		return nil, false, errors.New("registration path is a directory")
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(content), true, nil
+}
+
+// isCountersigned checks the central countersignature file for the repository
+func (r *RepoAction) isCountersigned(ctx context.Context, params *RepoActionParams) (bool, error) {
+	file, _, _, err := r.client.GetV3Client().Repositories.GetContents(ctx, r.client.Organization(), r.client.Repository(), r.sources.CountersignaturePath, &github.RepositoryContentGetOptions{Ref: "main"})
+	if err != nil {
+		return false, err
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return false, err
+	}
+
+	return hasCountersignature([]byte(content), fmt.Sprintf("%s/%s/%s", r.enterpriseURL, params.ValidationOrganization, params.ValidationRepository))
+}
+
+// hasCountersignature reports whether the countersignature list contains the repository url
+func hasCountersignature(content []byte, repositoryURL string) (bool, error) {
+	var countersigned []string
+	if err := yaml.Unmarshal(content, &countersigned); err != nil {
+		return false, err
+	}
+	return containsString(countersigned, repositoryURL), nil
+}
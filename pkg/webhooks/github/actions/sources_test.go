@@ -0,0 +1,114 @@
+package actions
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+func TestNewRegistrationSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources config.RegistrationSourcesConfig
+		want    config.RegistrationSourcesConfig
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			sources: config.RegistrationSourcesConfig{},
+			want: config.RegistrationSourcesConfig{
+				Order:                []string{"central"},
+				RepositoryPath:       ".github/actions-registration.yml",
+				CountersignaturePath: "countersignatures.yml",
+			},
+		},
+		{
+			name: "repository first",
+			sources: config.RegistrationSourcesConfig{
+				Order:                   []string{"repository", "central"},
+				RepositoryPath:          ".github/registration.yml",
+				RequireCountersignature: true,
+				CountersignaturePath:    "approved.yml",
+			},
+			want: config.RegistrationSourcesConfig{
+				Order:                   []string{"repository", "central"},
+				RepositoryPath:          ".github/registration.yml",
+				RequireCountersignature: true,
+				CountersignaturePath:    "approved.yml",
+			},
+		},
+		{
+			name:    "unknown source",
+			sources: config.RegistrationSourcesConfig{Order: []string{"wiki"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newRegistrationSources(tt.sources)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newRegistrationSources() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newRegistrationSources() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasCountersignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		url     string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "countersigned",
+			content: "- https://octodemo.com/mo-octocat/other\n- https://octodemo.com/mo-octocat/flutter-template\n",
+			url:     "https://octodemo.com/mo-octocat/flutter-template",
+			want:    true,
+		},
+		{
+			name:    "not countersigned",
+			content: "- https://octodemo.com/mo-octocat/other\n",
+			url:     "https://octodemo.com/mo-octocat/flutter-template",
+			want:    false,
+		},
+		{
+			name:    "malformed",
+			content: "repos: [",
+			url:     "https://octodemo.com/mo-octocat/flutter-template",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hasCountersignature([]byte(tt.content), tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("hasCountersignature() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("hasCountersignature() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepoAction_handleRepoConfig(t *testing.T) {
+	r := &RepoAction{
+		logger:  zap.NewNop().Sugar(),
+		sources: config.RegistrationSourcesConfig{Order: []string{"unknown"}},
+	}
+	params := &RepoActionParams{ValidationOrganization: "mo-octocat", ValidationRepository: "flutter-template"}
+	if _, err := r.handleRepoConfig(context.Background(), params); err == nil {
+		t.Errorf("handleRepoConfig() expected error for unknown source")
+	}
+}
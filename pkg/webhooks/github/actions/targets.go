@@ -0,0 +1,87 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// issueRepository is a repository workflow issues are filed into, with the client of its installation
+type issueRepository struct {
+	owner  string
+	name   string
+	client *clients.Github
+}
+
+func (r *issueRepository) String() string {
+	return r.owner + "/" + r.name
+}
+
+// issueRef points to an issue, the repository is given as owner/name
+type issueRef struct {
+	Repository string
+	Number     int
+}
+
+func splitRepository(fullName string) (string, string, error) {
+	owner, name, ok := strings.Cut(fullName, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf(utils.ErrInvalidIssueRepository, fullName)
+	}
+	return owner, name, nil
+}
+
+func newIssueTarget(c config.IssueTargetConfig) (config.IssueTargetConfig, error) {
+	switch c.Target {
+	case "":
+		c.Target = utils.IssueTargetCentral
+	case utils.IssueTargetCentral, utils.IssueTargetRepository, utils.IssueTargetBoth:
+	default:
+		return c, fmt.Errorf(utils.ErrInvalidIssueTarget, c.Target)
+	}
+	if c.Repository != "" {
+		if _, _, err := splitRepository(c.Repository); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// filesCentral reports whether workflow issues are filed into the central repository
+func (w *WorkflowAction) filesCentral() bool {
+	return w.issueTarget.Target != utils.IssueTargetRepository
+}
+
+// filesRepository reports whether workflow issues are filed into the offending repository
+func (w *WorkflowAction) filesRepository() bool {
+	return w.issueTarget.Target == utils.IssueTargetRepository || w.issueTarget.Target == utils.IssueTargetBoth
+}
+
+// centralRepository is the repository of the central workflow issues, the repository of the client by default
+func (w *WorkflowAction) centralRepository() string {
+	if w.issueTarget.Repository != "" {
+		return w.issueTarget.Repository
+	}
+	return fmt.Sprintf("%s/%s", w.organization, w.repository)
+}
+
+// issueRepository resolves the client of a repository given as owner/name. Repositories other than the one of
+// the client are accessed through the app installation that covers them, which may belong to another organization.
+func (w *WorkflowAction) issueRepository(fullName string) (*issueRepository, error) {
+	owner, name, err := splitRepository(fullName)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(owner, w.organization) && strings.EqualFold(name, w.repository) {
+		return &issueRepository{owner: w.organization, name: w.repository, client: w.client}, nil
+	}
+
+	client, err := w.client.ForRepository(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	return &issueRepository{owner: owner, name: name, client: client}, nil
+}
@@ -0,0 +1,107 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+func TestNewIssueTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.IssueTargetConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: utils.IssueTargetCentral},
+		{name: "both", config: config.IssueTargetConfig{Target: "both", Repository: "mo-tools/actions-issues"}, want: utils.IssueTargetBoth},
+		{name: "unknown target", config: config.IssueTargetConfig{Target: "elsewhere"}, wantErr: true},
+		{name: "invalid repository", config: config.IssueTargetConfig{Repository: "actions-issues"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newIssueTarget(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newIssueTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Target != tt.want {
+				t.Errorf("newIssueTarget() target = %v, want %v", got.Target, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_issueTargets(t *testing.T) {
+	tests := []struct {
+		target          string
+		repository      string
+		wantCentral     bool
+		wantRepository  bool
+		wantCentralRepo string
+	}{
+		{target: utils.IssueTargetCentral, wantCentral: true, wantCentralRepo: "mo-octocat/actions-control"},
+		{target: utils.IssueTargetRepository, wantRepository: true, wantCentralRepo: "mo-octocat/actions-control"},
+		{target: utils.IssueTargetBoth, repository: "mo-tools/actions-issues", wantCentral: true, wantRepository: true, wantCentralRepo: "mo-tools/actions-issues"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := &WorkflowAction{organization: "mo-octocat", repository: "actions-control", issueTarget: config.IssueTargetConfig{Target: tt.target, Repository: tt.repository}}
+			if got := w.filesCentral(); got != tt.wantCentral {
+				t.Errorf("filesCentral() = %v, want %v", got, tt.wantCentral)
+			}
+			if got := w.filesRepository(); got != tt.wantRepository {
+				t.Errorf("filesRepository() = %v, want %v", got, tt.wantRepository)
+			}
+			if got := w.centralRepository(); got != tt.wantCentralRepo {
+				t.Errorf("centralRepository() = %v, want %v", got, tt.wantCentralRepo)
+			}
+		})
+	}
+}
+
+func TestWorkflowAction_issueRepository_own(t *testing.T) {
+	w := &WorkflowAction{organization: "mo-octocat", repository: "actions-control"}
+	r, err := w.issueRepository("mo-octocat/Actions-Control")
+	if err != nil {
+		t.Fatalf("issueRepository() error = %v", err)
+	}
+	if r.String() != "mo-octocat/actions-control" || r.client != w.client {
+		t.Errorf("issueRepository() = %+v, want the repository of the client", r)
+	}
+	if _, err := w.issueRepository("mo-octocat"); err == nil {
+		t.Errorf("issueRepository() error = nil, want invalid repository")
+	}
+}
+
+func TestOpenIssue_refs(t *testing.T) {
+	tests := []struct {
+		name   string
+		record openIssue
+		want   []issueRef
+	}{
+		{
+			name:   "legacy central issue",
+			record: openIssue{Organization: "mo-octocat", Repository: "flutter-template", Issue: 7},
+			want:   []issueRef{{Repository: "mo-octocat/actions-control", Number: 7}},
+		},
+		{
+			name:   "both",
+			record: openIssue{Organization: "mo-octocat", Repository: "flutter-template", IssueRepository: "mo-tools/actions-issues", Issue: 7, RepositoryIssue: 3},
+			want:   []issueRef{{Repository: "mo-tools/actions-issues", Number: 7}, {Repository: "mo-octocat/flutter-template", Number: 3}},
+		},
+		{
+			name:   "repository only",
+			record: openIssue{Organization: "mo-octocat", Repository: "flutter-template", RepositoryIssue: 3},
+			want:   []issueRef{{Repository: "mo-octocat/flutter-template", Number: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.record.refs("mo-octocat/actions-control"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("refs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// IssueLinks are the links available to issue templates
+type IssueLinks struct {
+	Repository string
+	Workflow   string
+	Run        string
+	Sender     string
+}
+
+// IssueTemplateData is the data model issue templates are executed with
+type IssueTemplateData struct {
+	Event         *WorkflowActionParams
+	EventType     string
+	EventName     string
+	Registration  *ValidatorData
+	Validation    *ValidationResult
+	Links         IssueLinks
+	EnterpriseURL string
+}
+
+// issueTemplates renders the title and body of workflow issues
+type issueTemplates struct {
+	title *template.Template
+	body  *template.Template
+}
+
+var eventNames = map[string]string{
+	"run":      "Workflow Run",
+	"dispatch": "Workflow Dispatch",
+	"job":      "Workflow Job",
+}
+
+// newIssueTemplates parses the templates and executes them against sample data, once with an applying registration
+// and once without any, so that templates referring to unknown fields or to a missing registration fail at startup.
+// read loads template files from the central repository.
+func newIssueTemplates(c config.IssueTemplatesConfig, read func(string) ([]byte, error)) (*issueTemplates, error) {
+	title, err := issueTemplateSource("title", c.Title, c.TitleFile, utils.DefaultIssueTitleTemplate, read)
+	if err != nil {
+		return nil, err
+	}
+	body, err := issueTemplateSource("body", c.Body, c.BodyFile, utils.DefaultIssueBodyTemplate, read)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := parseIssueTemplates(title, body)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &WorkflowActionParams{
+		WorkflowName: "build",
+		WorkflowID:   1,
+		WorkflowPath: ".github/workflows/build.yml",
+		Organization: "org",
+		Repository:   "repo",
+		Sender:       "octocat",
+		RunID:        1,
+	}
+	samples := []*ValidationResult{
+		{Files: []FileResult{{
+			Path:         "registrations/org.yml",
+			Applies:      true,
+			Registration: &ValidatorData{URL: "https://github.example.com/org", ContactEmail: "team@example.com", UseCase: "ci"},
+		}}},
+		{},
+	}
+	for _, sample := range samples {
+		if _, _, err := t.render(issueTemplateData("https://github.example.com", "run", event, sample)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// defaultIssueTemplates renders issues whose configured templates fail at runtime
+var defaultIssueTemplates = func() *issueTemplates {
+	t, err := parseIssueTemplates(utils.DefaultIssueTitleTemplate, utils.DefaultIssueBodyTemplate)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}()
+
+func parseIssueTemplates(title, body string) (*issueTemplates, error) {
+	var err error
+	t := &issueTemplates{}
+	if t.title, err = template.New("title").Option("missingkey=error").Parse(title); err != nil {
+		return nil, fmt.Errorf(utils.ErrInvalidIssueTemplate, "title", err)
+	}
+	if t.body, err = template.New("body").Option("missingkey=error").Parse(body); err != nil {
+		return nil, fmt.Errorf(utils.ErrInvalidIssueTemplate, "body", err)
+	}
+	return t, nil
+}
+
+func issueTemplateSource(name, inline, file, fallback string, read func(string) ([]byte, error)) (string, error) {
+	switch {
+	case inline != "" && file != "":
+		return "", fmt.Errorf(utils.ErrIssueTemplateSource, name, name, name+"_file")
+	case inline != "":
+		return inline, nil
+	case file != "":
+		content, err := read(file)
+		if err != nil {
+			return "", fmt.Errorf(utils.ErrInvalidIssueTemplate, name, err)
+		}
+		return string(content), nil
+	}
+	return fallback, nil
+}
+
+func issueTemplateData(enterpriseURL, eventType string, p *WorkflowActionParams, result *ValidationResult) IssueTemplateData {
+	repository := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(enterpriseURL, "/"), p.Organization, p.Repository)
+	links := IssueLinks{
+		Repository: repository,
+		Workflow:   repository + "/actions",
+		Sender:     fmt.Sprintf("%s/%s", strings.TrimSuffix(enterpriseURL, "/"), p.Sender),
+	}
+	if p.WorkflowPath != "" {
+		links.Workflow = fmt.Sprintf("%s/actions/workflows/%s", repository, path.Base(p.WorkflowPath))
+	}
+	links.Run = links.Workflow
+	if p.RunID != 0 {
+		links.Run = fmt.Sprintf("%s/actions/runs/%d", repository, p.RunID)
+	}
+
+	data := IssueTemplateData{
+		Event:         p,
+		EventType:     eventType,
+		EventName:     eventNames[eventType],
+		Validation:    result,
+		Links:         links,
+		EnterpriseURL: enterpriseURL,
+	}
+	if result != nil {
+		data.Registration = result.Registration()
+	}
+	return data
+}
+
+func (t *issueTemplates) render(data IssueTemplateData) (string, string, error) {
+	var title, body strings.Builder
+	if err := t.title.Execute(&title, data); err != nil {
+		return "", "", fmt.Errorf(utils.ErrInvalidIssueTemplate, "title", err)
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf(utils.ErrInvalidIssueTemplate, "body", err)
+	}
+	return strings.TrimSpace(title.String()), body.String(), nil
+}
+
+// renderIssue renders the issue of a workflow event that failed validation. The default templates are used when
+// the configured ones fail for the event, an issue is filed either way.
+func (w *WorkflowAction) renderIssue(eventType string, p *WorkflowActionParams, result *ValidationResult) (string, string, error) {
+	if _, ok := eventNames[eventType]; !ok {
+		return "", "", errors.New(utils.ErrUnsupportedEventType)
+	}
+	data := issueTemplateData(w.client.ServerInfo().EnterpriseURL, eventType, p, result)
+	title, body, err := w.templates.render(data)
+	if err != nil {
+		w.logger.Warnw("error rendering issue template, using the default", "organization", p.Organization, "repository", p.Repository, "error", err)
+		return defaultIssueTemplates.render(data)
+	}
+	return title, body, nil
+}
+
+// loadIssueTemplates reads template files from the central repository at startup
+func (w *WorkflowAction) loadIssueTemplates(c config.IssueTemplatesConfig) error {
+	templates, err := newIssueTemplates(c, func(file string) ([]byte, error) {
+		return w.repoAction().readFile(context.Background(), file)
+	})
+	if err != nil {
+		return err
+	}
+	w.templates = templates
+	return nil
+}
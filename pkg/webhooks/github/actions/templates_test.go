@@ -0,0 +1,128 @@
+package actions
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.tools.sap/actions-rollout-app/config"
+)
+
+func TestNewIssueTemplates(t *testing.T) {
+	files := map[string]string{
+		"templates/title.tmpl": "{{ .Event.Repository }} failed validation",
+	}
+	read := func(file string) ([]byte, error) {
+		content, ok := files[file]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return []byte(content), nil
+	}
+
+	tests := []struct {
+		name    string
+		config  config.IssueTemplatesConfig
+		wantErr bool
+	}{
+		{name: "defaults", config: config.IssueTemplatesConfig{}},
+		{name: "inline", config: config.IssueTemplatesConfig{Body: "{{ with .Registration }}{{ .ContactEmail }}{{ end }}"}},
+		{name: "file", config: config.IssueTemplatesConfig{TitleFile: "templates/title.tmpl"}},
+		{name: "missing file", config: config.IssueTemplatesConfig{BodyFile: "templates/body.tmpl"}, wantErr: true},
+		{name: "inline and file", config: config.IssueTemplatesConfig{Title: "title", TitleFile: "templates/title.tmpl"}, wantErr: true},
+		{name: "syntax error", config: config.IssueTemplatesConfig{Title: "{{ .Event.Repository"}, wantErr: true},
+		{name: "unknown field", config: config.IssueTemplatesConfig{Body: "{{ .Event.Unknown }}"}, wantErr: true},
+		{name: "registration without guard", config: config.IssueTemplatesConfig{Body: "{{ .Registration.ContactEmail }}"}, wantErr: true},
+		{name: "first file without guard", config: config.IssueTemplatesConfig{Title: "{{ (index .Validation.Files 0).Path }}"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newIssueTemplates(tt.config, read)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newIssueTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIssueTemplates_render(t *testing.T) {
+	templates, err := newIssueTemplates(config.IssueTemplatesConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &WorkflowActionParams{
+		WorkflowName: "build",
+		WorkflowID:   42,
+		WorkflowPath: ".github/workflows/build.yml",
+		Organization: "mo-octocat",
+		Repository:   "flutter-template",
+		Sender:       "octocat",
+		RunID:        7,
+	}
+	result := &ValidationResult{Files: []FileResult{{Path: "registrations/mo-octocat.yml", Applies: true, Failures: []string{"invalid contact email or empty"}}}}
+
+	title, body, err := templates.render(issueTemplateData("https://octodemo.com/", "run", p, result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "[42] - mo-octocat/flutter-template" {
+		t.Errorf("render() title = %q", title)
+	}
+	for _, want := range []string{
+		"<a href='https://octodemo.com/mo-octocat/flutter-template/actions/runs/7'",
+		"[mo-octocat/flutter-template](https://octodemo.com/mo-octocat/flutter-template) on Workflow Run event",
+		"[build](https://octodemo.com/mo-octocat/flutter-template/actions/workflows/build.yml)",
+		"| @octocat ",
+		":x: invalid contact email or empty",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("render() body does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestWorkflowAction_renderIssue(t *testing.T) {
+	w := newTestWorkflowAction(t, map[string]any{
+		"issue_templates": map[string]any{"body": `{{ if eq .Event.Repository "broken" }}{{ .Event.Unknown }}{{ end }}custom`},
+	}, nil)
+
+	tests := []struct {
+		name       string
+		repository string
+		want       string
+	}{
+		{name: "configured template", repository: "flutter-template", want: "custom"},
+		{name: "failing template falls back to the default", repository: "broken", want: "## Actions Controller"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &WorkflowActionParams{WorkflowName: "build", WorkflowID: 42, Organization: testOrganization, Repository: tt.repository, Sender: "octocat"}
+			_, body, err := w.renderIssue("run", p, &ValidationResult{})
+			if err != nil {
+				t.Fatalf("renderIssue() error = %v", err)
+			}
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("renderIssue() body = %q, want it to contain %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestIssueTemplateData_links(t *testing.T) {
+	p := &WorkflowActionParams{Organization: "org", Repository: "repo", Sender: "octocat"}
+	data := issueTemplateData("https://octodemo.com", "job", p, nil)
+
+	want := IssueLinks{
+		Repository: "https://octodemo.com/org/repo",
+		Workflow:   "https://octodemo.com/org/repo/actions",
+		Run:        "https://octodemo.com/org/repo/actions",
+		Sender:     "https://octodemo.com/octocat",
+	}
+	if data.Links != want {
+		t.Errorf("issueTemplateData() links = %+v, want %+v", data.Links, want)
+	}
+	if data.EventName != "Workflow Job" || data.Registration != nil {
+		t.Errorf("issueTemplateData() = %+v", data)
+	}
+}
@@ -0,0 +1,162 @@
+package actions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.tools.sap/actions-rollout-app/pkg/analysis"
+	"github.tools.sap/actions-rollout-app/utils"
+)
+
+// FileResult is the outcome of checking a single registration file against a repository
+type FileResult struct {
+	Path     string   `json:"path"`
+	Source   string   `json:"source,omitempty"`
+	Applies  bool     `json:"applies"`
+	Failures []string `json:"failures,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	// Errors are checks that could not be completed, e.g. lookups that failed, the registration is not valid while
+	// they remain
+	Errors []string `json:"errors,omitempty"`
+	// Registration is the parsed registration, it is only set when the registration applies
+	Registration *ValidatorData `json:"registration,omitempty"`
+}
+
+// Valid reports whether the registration applies to the repository and all rules hold
+func (f *FileResult) Valid() bool {
+	return f.Applies && len(f.Failures) == 0 && len(f.Errors) == 0
+}
+
+// problems returns the failures followed by the errors
+func (f *FileResult) problems() []string {
+	return append(append([]string{}, f.Failures...), f.Errors...)
+}
+
+// ValidationResult lists every registration file inspected for a repository
+type ValidationResult struct {
+	Organization string             `json:"organization"`
+	Repository   string             `json:"repository"`
+	Files        []FileResult       `json:"files"`
+	Errors       []string           `json:"errors,omitempty"`
+	Findings     []analysis.Finding `json:"findings,omitempty"`
+}
+
+// Valid reports whether at least one registration file validates the repository and the workflow has no findings
+func (v *ValidationResult) Valid() bool {
+	return v.Registered() && len(v.Findings) == 0
+}
+
+// Registered reports whether at least one registration file validates the repository
+func (v *ValidationResult) Registered() bool {
+	if v == nil {
+		return false
+	}
+	for i := range v.Files {
+		if v.Files[i].Valid() {
+			return true
+		}
+	}
+	return false
+}
+
+// Registration returns the registration that validates the repository, or the first one that applies
+func (v *ValidationResult) Registration() *ValidatorData {
+	if v == nil {
+		return nil
+	}
+	for _, file := range v.Files {
+		if file.Valid() {
+			return file.Registration
+		}
+	}
+	for _, file := range v.Applicable() {
+		return file.Registration
+	}
+	return nil
+}
+
+// Applicable returns the files that target the repository
+func (v *ValidationResult) Applicable() []FileResult {
+	var files []FileResult
+	for _, file := range v.Files {
+		if file.Applies {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Err summarizes why the repository is not valid, it returns nil for a valid repository
+func (v *ValidationResult) Err() error {
+	if v.Valid() {
+		return nil
+	}
+
+	if v.Registered() {
+		var findings []string
+		for _, finding := range v.Findings {
+			findings = append(findings, finding.String())
+		}
+		return fmt.Errorf(utils.ErrWorkflowFindings, v.Organization, v.Repository, strings.Join(findings, "; "))
+	}
+
+	applicable := v.Applicable()
+	if len(applicable) == 0 {
+		return fmt.Errorf(utils.ErrNoRegistrationFound, v.Organization, v.Repository)
+	}
+
+	var reasons []string
+	for _, file := range applicable {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", file.Path, strings.Join(file.problems(), ", ")))
+	}
+	return fmt.Errorf(utils.ErrRegistrationInvalid, v.Organization, v.Repository, strings.Join(reasons, "; "))
+}
+
+// Markdown renders the result as a table for issue bodies
+func (v *ValidationResult) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("\n\n### :mag: Validation\n")
+	if len(v.Files) == 0 {
+		b.WriteString("No registration files were found.\n")
+	} else {
+		b.WriteString("| File | Applies | Result |\n")
+		b.WriteString("| -----|---------|--------|\n")
+		for _, file := range v.Files {
+			status := ":white_check_mark: valid"
+			switch {
+			case !file.Applies:
+				status = "-"
+			case !file.Valid():
+				status = ":x: " + strings.Join(file.problems(), "<br>")
+			}
+			if len(file.Warnings) > 0 {
+				status += "<br>:warning: " + strings.Join(file.Warnings, "<br>:warning: ")
+			}
+			fmt.Fprintf(&b, "| %s | %t | %s |\n", file.Path, file.Applies, status)
+		}
+	}
+
+	for _, err := range v.Errors {
+		fmt.Fprintf(&b, "\n:warning: %s", err)
+	}
+
+	if len(v.Findings) > 0 {
+		b.WriteString("\n\n### :microscope: Workflow analysis\n")
+		b.WriteString("| Rule | Job | Finding |\n")
+		b.WriteString("| -----|-----|---------|\n")
+		for _, finding := range v.Findings {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", finding.Rule, finding.Job, finding.Message)
+		}
+	}
+
+	return b.String()
+}
+
+func (v *ValidationResult) sort() {
+	sort.Slice(v.Files, func(i, j int) bool {
+		return v.Files[i].Path < v.Files[j].Path
+	})
+	sort.Strings(v.Errors)
+}
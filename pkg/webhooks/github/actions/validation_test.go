@@ -0,0 +1,136 @@
+package actions
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.tools.sap/actions-rollout-app/pkg/analysis"
+)
+
+func TestRepoAction_handleRepoConfigFileContent(t *testing.T) {
+	params := &RepoActionParams{ValidationOrganization: "mo-octocat", ValidationRepository: "flutter-template"}
+	tests := []struct {
+		name    string
+		content string
+		want    FileResult
+	}{
+		{
+			name:    "valid org wide registration",
+			content: "url: https://octodemo.com/mo-octocat\ncontactEmail: team@example.com\nuseCase: mo-octocat\n",
+			want: FileResult{Applies: true, Registration: &ValidatorData{
+				URL: "https://octodemo.com/mo-octocat", ContactEmail: "team@example.com", UseCase: "mo-octocat",
+			}},
+		},
+		{
+			name:    "valid repo registration among others",
+			content: "url: https://octodemo.com/mo-octocat\ncontactEmail: team@example.com\nuseCase: mo-octocat\nrepos:\n  - https://octodemo.com/mo-octocat/other\n  - https://octodemo.com/mo-octocat/flutter-template\n",
+			want: FileResult{Applies: true, Registration: &ValidatorData{
+				URL: "https://octodemo.com/mo-octocat", ContactEmail: "team@example.com", UseCase: "mo-octocat",
+				Repos: []string{"https://octodemo.com/mo-octocat/other", "https://octodemo.com/mo-octocat/flutter-template"},
+			}},
+		},
+		{
+			name:    "other organization",
+			content: "url: https://octodemo.com/other\ncontactEmail: team@example.com\nuseCase: other\n",
+			want:    FileResult{},
+		},
+		{
+			name:    "other repository",
+			content: "url: https://octodemo.com/mo-octocat\ncontactEmail: team@example.com\nuseCase: mo-octocat\nrepos:\n  - https://octodemo.com/mo-octocat/other\n",
+			want:    FileResult{},
+		},
+		{
+			name:    "all failures are reported",
+			content: "url: https://octodemo.com/mo-octocat\nuseCase: ci\nexpires: 2020-01-01\n",
+			want: FileResult{Applies: true, Failures: []string{
+				"invalid contact email or empty",
+				"invalid use case or empty: ci",
+				"registration expired: 2020-01-01",
+			}, Registration: &ValidatorData{URL: "https://octodemo.com/mo-octocat", UseCase: "ci", Expires: "2020-01-01"}},
+		},
+		{
+			name:    "malformed yaml",
+			content: "url: [",
+			want:    FileResult{Failures: []string{"yaml: line 1: did not find expected node content"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &RepoAction{logger: zap.NewNop().Sugar(), enterpriseURL: "https://octodemo.com"}
+			if got := r.handleRepoConfigFileContent(context.Background(), params, []byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("handleRepoConfigFileContent() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationResult_Err(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  *ValidationResult
+		wantErr string
+	}{
+		{
+			name: "valid",
+			result: &ValidationResult{Organization: "mo-octocat", Repository: "flutter-template", Files: []FileResult{
+				{Path: "orgs/a.yml"},
+				{Path: "orgs/b.yml", Applies: true},
+			}},
+		},
+		{
+			name:    "no registration",
+			result:  &ValidationResult{Organization: "mo-octocat", Repository: "flutter-template", Files: []FileResult{{Path: "orgs/a.yml"}}},
+			wantErr: "no valid files found in repository mo-octocat/flutter-template",
+		},
+		{
+			name: "invalid registration",
+			result: &ValidationResult{Organization: "mo-octocat", Repository: "flutter-template", Files: []FileResult{
+				{Path: "orgs/a.yml", Applies: true, Failures: []string{"invalid use case or empty: ci"}},
+			}},
+			wantErr: "repository mo-octocat/flutter-template is not valid: orgs/a.yml: invalid use case or empty: ci",
+		},
+		{
+			name: "workflow findings",
+			result: &ValidationResult{Organization: "mo-octocat", Repository: "flutter-template",
+				Files:    []FileResult{{Path: "orgs/a.yml", Applies: true}},
+				Findings: []analysis.Finding{{Rule: "write-all-permissions", Message: "grants write-all permissions to the workflow"}},
+			},
+			wantErr: "workflow of mo-octocat/flutter-template is not valid: write-all-permissions: grants write-all permissions to the workflow",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.result.Err()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Err() = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidationResult_Markdown(t *testing.T) {
+	result := &ValidationResult{
+		Files: []FileResult{
+			{Path: "orgs/a.yml", Applies: true, Failures: []string{"invalid use case or empty: ci"}},
+			{Path: "orgs/b.yml"},
+		},
+		Errors: []string{"orgs-wdf: 404 Not Found"},
+	}
+	got := result.Markdown()
+	for _, want := range []string{
+		"| orgs/a.yml | true | :x: invalid use case or empty: ci |",
+		"| orgs/b.yml | false | - |",
+		":warning: orgs-wdf: 404 Not Found",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown() = %q, missing %q", got, want)
+		}
+	}
+}
@@ -6,39 +6,93 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 
 	ghwebhooks "github.com/go-playground/webhooks/v6/github"
 	"github.com/google/go-github/v50/github"
 	"go.uber.org/zap"
 
 	"github.tools.sap/actions-rollout-app/config"
+	"github.tools.sap/actions-rollout-app/pkg/analysis"
+	"github.tools.sap/actions-rollout-app/pkg/audit"
+	"github.tools.sap/actions-rollout-app/pkg/breaker"
 	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/notify"
+	"github.tools.sap/actions-rollout-app/pkg/policy"
+	"github.tools.sap/actions-rollout-app/pkg/state"
 	"github.tools.sap/actions-rollout-app/utils"
 )
 
 type WorkflowActionParams struct {
 	WorkflowName string
 	WorkflowID   int64
+	WorkflowPath string
 	Organization string
 	Repository   string
 	WebhookEvent ghwebhooks.Event
+	Action       string
 	Sender       string
+	SenderType   string
+	RunID        int64
+	HeadBranch   string
+	HeadSHA      string
+	Repo         RepositoryMetadata
+}
+
+type RepositoryMetadata struct {
+	ID            int64
+	FullName      string
+	Private       bool
+	Visibility    string
+	DefaultBranch string
+	Archived      bool
+	Fork          bool
 }
 
 type WorkflowAction struct {
 	logger *zap.SugaredLogger
 	client *clients.Github
 
-	repository     string
-	organization   string
-	workerPoolSize float64
-	filesPath      *[]string
-	assignees      *[]string
+	repository      string
+	organization    string
+	workerPoolSize  float64
+	filesPath       *[]string
+	assignees       *[]string
+	expiry          config.RegistrationExpiryConfig
+	contact         config.ContactVerificationConfig
+	verifier        *contactVerifier
+	sources         config.RegistrationSourcesConfig
+	policies        *policy.Engine
+	escalation      config.EscalationConfig
+	remediation     config.RemediationConfig
+	issueResolution config.IssueResolutionConfig
+	checkRuns       config.CheckRunsConfig
+	issueTarget     config.IssueTargetConfig
+	digest          config.DigestConfig
+	assigneeRouter  *assigneeRouter
+	cancel          config.CancelRunConfig
+	exemptions      *exemptionRegistry
+	runnerGroups    config.RunnerGroupsConfig
+	analyzer        *analysis.Analyzer
+	templates       *issueTemplates
+	issueMu         sync.Mutex
+	labels          []*labelTemplate
+	labelMu         sync.Mutex
+	knownLabels     map[string]bool
+	audit           *audit.Recorder
+	state           *state.Store
+	breaker         *breaker.Breaker
+	notifier        *notify.Dispatcher
+	runs            *runTracker
+	violationMu     keyedMutex
+
+	enforcement       config.Enforcement
+	globalEnforcement config.Enforcement
 }
 
 // TODO: retest this
 
-func NewWorkflowAction(logger *zap.SugaredLogger, client *clients.Github, rawConfig map[string]any) (*WorkflowAction, error) {
+func NewWorkflowAction(logger *zap.SugaredLogger, client *clients.Github, rawConfig map[string]any, deps *Dependencies) (*WorkflowAction, error) {
 	filesInterface, ok := rawConfig["files_path"].([]interface{})
 	if !ok {
 		return nil, errors.New("filesPath not found or is not a slice of interface{}")
@@ -64,15 +118,206 @@ func NewWorkflowAction(logger *zap.SugaredLogger, client *clients.Github, rawCon
 		assignees[i] = str
 	}
 
+	var expiry config.RegistrationExpiryConfig
+	if err := decodeArg(rawConfig, "registration_expiry", &expiry); err != nil {
+		return nil, err
+	}
+
+	var contact config.ContactVerificationConfig
+	if err := decodeArg(rawConfig, "contact_verification", &contact); err != nil {
+		return nil, err
+	}
+	if contact.Failure == "" {
+		contact.Failure = utils.ContactFailureSoft
+	}
+	if contact.Failure != utils.ContactFailureSoft && contact.Failure != utils.ContactFailureHard {
+		return nil, fmt.Errorf(utils.ErrInvalidContactFailure, contact.Failure)
+	}
+
+	var sources config.RegistrationSourcesConfig
+	if err := decodeArg(rawConfig, "registration_sources", &sources); err != nil {
+		return nil, err
+	}
+	sources, err := newRegistrationSources(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []config.Policy
+	if err := decodeArg(rawConfig, "policies", &policies); err != nil {
+		return nil, err
+	}
+	engine, err := policy.New(policies)
+	if err != nil {
+		return nil, err
+	}
+
+	var enforcement config.Enforcement
+	if err := decodeArg(rawConfig, "enforcement", &enforcement); err != nil {
+		return nil, err
+	}
+	if err := validateEnforcement(enforcement); err != nil {
+		return nil, err
+	}
+	if err := validateEnforcement(deps.Enforcement); err != nil {
+		return nil, err
+	}
+
+	var escalation config.EscalationConfig
+	if err := decodeArg(rawConfig, "escalation", &escalation); err != nil {
+		return nil, err
+	}
+	escalation, err = newEscalation(escalation)
+	if err != nil {
+		return nil, err
+	}
+
+	var remediation config.RemediationConfig
+	if err := decodeArg(rawConfig, "remediation", &remediation); err != nil {
+		return nil, err
+	}
+	if remediation.Interval <= 0 {
+		remediation.Interval = utils.DefaultRemediationInterval
+	}
+
+	var issueResolution config.IssueResolutionConfig
+	if err := decodeArg(rawConfig, "issue_resolution", &issueResolution); err != nil {
+		return nil, err
+	}
+	if issueResolution.Interval <= 0 {
+		issueResolution.Interval = utils.DefaultIssueResolutionInterval
+	}
+	if issueResolution.Label == "" {
+		issueResolution.Label = utils.LabelResolved
+	}
+
+	var checkRuns config.CheckRunsConfig
+	if err := decodeArg(rawConfig, "check_runs", &checkRuns); err != nil {
+		return nil, err
+	}
+	checkRuns, err = newCheckRuns(checkRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	var issueTarget config.IssueTargetConfig
+	if err := decodeArg(rawConfig, "issue_target", &issueTarget); err != nil {
+		return nil, err
+	}
+	issueTarget, err = newIssueTarget(issueTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	var digest config.DigestConfig
+	if err := decodeArg(rawConfig, "digest", &digest); err != nil {
+		return nil, err
+	}
+	digest, err = newDigestConfig(digest, deps.Audit)
+	if err != nil {
+		return nil, err
+	}
+
+	var cancel config.CancelRunConfig
+	if err := decodeArg(rawConfig, "cancel_run", &cancel); err != nil {
+		return nil, err
+	}
+
+	var exemptionsConfig config.ExemptionsConfig
+	if err := decodeArg(rawConfig, "exemptions", &exemptionsConfig); err != nil {
+		return nil, err
+	}
+	exemptions, err := newExemptionRegistry(exemptionsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var runnerGroups config.RunnerGroupsConfig
+	if err := decodeArg(rawConfig, "runner_groups", &runnerGroups); err != nil {
+		return nil, err
+	}
+	if runnerGroups.Interval <= 0 {
+		runnerGroups.Interval = utils.DefaultRunnerGroupsInterval
+	}
+
+	var workflowAnalysis config.WorkflowAnalysisConfig
+	if err := decodeArg(rawConfig, "workflow_analysis", &workflowAnalysis); err != nil {
+		return nil, err
+	}
+	analyzer, err := newAnalyzer(workflowAnalysis.Enabled, workflowAnalysis.Rules, workflowAnalysis.TrustedOwners)
+	if err != nil {
+		return nil, err
+	}
+
+	var issueLabels []config.IssueLabel
+	if err := decodeArg(rawConfig, "issue_labels", &issueLabels); err != nil {
+		return nil, err
+	}
+	labels, err := parseLabels(issueLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	var issueTemplates config.IssueTemplatesConfig
+	if err := decodeArg(rawConfig, "issue_templates", &issueTemplates); err != nil {
+		return nil, err
+	}
+
+	var assigneeRouting config.AssigneeRoutingConfig
+	if err := decodeArg(rawConfig, "assignee_routing", &assigneeRouting); err != nil {
+		return nil, err
+	}
+	assigneeRouter, err := newAssigneeRouter(logger, assigneeRouting, assignees, &githubDirectory{client: client})
+	if err != nil {
+		return nil, err
+	}
+
+	var verifier *contactVerifier
+	if contact.Enabled {
+		verifier = newContactVerifier(&githubDirectory{client: client}, contact.CacheTTL)
+	}
+
 	// Create WorkflowAction object using struct initialization
-	return &WorkflowAction{
-		logger:       logger,
-		client:       client,
-		repository:   client.Repository(),
-		organization: client.Organization(),
-		filesPath:    &files,
-		assignees:    &assignees,
-	}, nil
+	w := &WorkflowAction{
+		logger:          logger,
+		client:          client,
+		repository:      client.Repository(),
+		organization:    client.Organization(),
+		filesPath:       &files,
+		assignees:       &assignees,
+		expiry:          expiry,
+		contact:         contact,
+		verifier:        verifier,
+		sources:         sources,
+		policies:        engine,
+		escalation:      escalation,
+		remediation:     remediation,
+		issueResolution: issueResolution,
+		checkRuns:       checkRuns,
+		issueTarget:     issueTarget,
+		digest:          digest,
+		assigneeRouter:  assigneeRouter,
+		cancel:          cancel,
+		exemptions:      exemptions,
+		runnerGroups:    runnerGroups,
+		analyzer:        analyzer,
+		labels:          labels,
+		knownLabels:     make(map[string]bool),
+		audit:           deps.Audit,
+		state:           deps.State,
+		breaker:         deps.Breaker,
+		notifier:        deps.Notifier,
+		runs:            newRunTracker(utils.DefaultRunDeliveryTTL),
+
+		enforcement:       enforcement,
+		globalEnforcement: deps.Enforcement,
+	}
+	if err := w.loadIssueTemplates(issueTemplates); err != nil {
+		return nil, err
+	}
+	deps.Breaker.OnTrip(w.alertCircuitBreaker)
+
+	return w, nil
 }
 
 func (w *WorkflowAction) HandleWorkflow(ctx context.Context, p *WorkflowActionParams) error {
@@ -106,51 +351,52 @@ func (w *WorkflowAction) HandleWorkflow(ctx context.Context, p *WorkflowActionPa
 }
 
 func (w *WorkflowAction) disableWorkflow(ctx context.Context, p *WorkflowActionParams, workflowID int64) error {
-	c := config.Client{
-		GithubAuthConfig: w.client.GetConfig(),
-		Name:             "disable-workflow",
-		OrganizationName: p.Organization,
-		RepositoryName:   p.Repository,
-		ServerInfo:       w.client.ServerInfo(),
-	}
-	configClients := []config.Client{c}
-	workflowClients, err := clients.InitClients(w.logger, configClients)
+	workflowClient, err := w.client.ForRepository(p.Organization, p.Repository)
 	if err != nil {
 		return err
 	}
 
-	for _, workflowClient := range workflowClients {
-		resp, workflowErr := workflowClient.GetV3Client().Actions.DisableWorkflowByID(ctx, p.Organization, p.Repository, workflowID)
-		if workflowErr != nil {
-			return workflowErr
-		}
+	resp, err := workflowClient.GetV3Client().Actions.DisableWorkflowByID(ctx, p.Organization, p.Repository, workflowID)
+	if err != nil {
+		return err
+	}
 
-		if resp.StatusCode != http.StatusNoContent {
-			return errors.New(strconv.Itoa(resp.StatusCode))
-		}
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New(strconv.Itoa(resp.StatusCode))
 	}
 
 	return nil
 }
 
-func (w *WorkflowAction) createWorkflowIssue(ctx context.Context, title, message string, assignees, labels []string) error {
+func (w *WorkflowAction) createWorkflowIssue(ctx context.Context, title, message string, assignees, labels []string) (*github.Issue, error) {
+	return w.createIssue(ctx, &issueRepository{owner: w.organization, name: w.repository, client: w.client}, title, message, assignees, labels)
+}
+
+func (w *WorkflowAction) createIssue(ctx context.Context, r *issueRepository, title, message string, assignees, labels []string) (*github.Issue, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	issue, issueResp, err := w.client.GetV3Client().Issues.Create(ctx, w.organization, w.repository, &github.IssueRequest{
+	if w.assigneeRouter != nil {
+		assignees = w.assigneeRouter.assignable(ctx, r, assignees)
+	}
+	if assignees == nil {
+		assignees = []string{}
+	}
+
+	issue, issueResp, err := r.client.GetV3Client().Issues.Create(ctx, r.owner, r.name, &github.IssueRequest{
 		Title:     github.String(title),
 		Body:      github.String(message),
 		Assignees: &assignees,
 		Labels:    &labels,
 	})
 	if err != nil {
-		w.logger.Errorw("error creating issue", "error", err)
-		return err
+		w.logger.Errorw("error creating issue", "repository", r.String(), "error", err)
+		return nil, err
 	}
 
-	w.logger.Infow("issue created", "issue_id", issue.ID, "response", issueResp.Response.StatusCode)
-	return nil
+	w.logger.Infow("issue created", "repository", r.String(), "issue_id", issue.ID, "response", issueResp.Response.StatusCode)
+	return issue, nil
 }
 
 func (w *WorkflowAction) handleWorkflowRun(ctx context.Context, p *WorkflowActionParams) error {
@@ -180,142 +426,153 @@ func (w *WorkflowAction) handleWorkflowJob(ctx context.Context, p *WorkflowActio
 }
 
 func (w *WorkflowAction) handleWorkflowEvent(ctx context.Context, p *WorkflowActionParams, eventType string) error {
-	title := fmt.Sprintf("[%d] - %s/%s", p.WorkflowID, p.Organization, p.Repository)
-	message, err := w.generateWorkflowMessage(eventType, p)
-
-	if err != nil {
-		return err
+	if _, ok := eventNames[eventType]; !ok {
+		return errors.New(utils.ErrUnsupportedEventType)
 	}
 
-	repoAction := &RepoAction{
-		logger:                 w.logger,
-		client:                 w.client,
-		validationOrganization: w.organization,
-		validationRepository:   w.repository,
-		filesPath:              w.filesPath,
-		workerPoolSize:         w.workerPoolSize,
-		assignees:              w.assignees,
-	}
+	repoAction := w.repoAction()
 
 	repoParams := &RepoActionParams{
 		ValidationOrganization: p.Organization,
 		ValidationRepository:   p.Repository,
+		Event:                  p,
 	}
 
-	err = repoAction.HandleRepo(ctx, repoParams)
+	result, err := repoAction.HandleRepo(ctx, repoParams)
+	if err == nil {
+		w.analyzeWorkflow(ctx, p, result)
+		err = result.Err()
+	}
+	w.recordAudit(p, utils.AuditActionValidated, result)
 	if err != nil {
+		if !w.runs.first(p.RunID) {
+			w.logger.Debugw("workflow run already handled", "organization", p.Organization, "repository", p.Repository, "run_id", p.RunID, "action", p.Action)
+			return nil
+		}
+
+		if exemption := w.exemptionFor(ctx, p); exemption != nil {
+			w.logger.Infow("workflow exempted", "scope", exemptionScope(*exemption), "reason", exemption.Reason, "approver", exemption.Approver)
+			w.recordAudit(p, utils.AuditActionExempted, result)
+			return nil
+		}
+
+		if w.enforcementMode(p.Organization) == utils.EnforcementModeAudit {
+			w.logger.Infow("audit mode, workflow not disabled", "organization", p.Organization, "repository", p.Repository, "workflow_id", p.WorkflowID)
+			w.recordAudit(p, utils.AuditActionWouldDisable, result)
+			return nil
+		}
+
+		if w.escalation.Enabled {
+			if err := w.escalate(ctx, p, eventType, result); err != nil {
+				w.runs.forget(p.RunID)
+				return err
+			}
+			return nil
+		}
 
-		disableErr := w.disableWorkflow(ctx, p, p.WorkflowID)
-		if disableErr != nil {
-			return disableErr
+		if !w.breaker.Allow(ctx, p.Organization) {
+			w.logger.Warnw("circuit breaker open, workflow not disabled", "organization", p.Organization, "repository", p.Repository, "workflow_id", p.WorkflowID)
+			w.recordAudit(p, utils.AuditActionWouldDisable, result)
+			return nil
 		}
-		w.logger.Infow("workflow disabled", "workflow_id", p.WorkflowID)
-		return w.createWorkflowIssue(ctx, title, message, *w.assignees, []string{fmt.Sprintf("%s/%s", p.Organization, p.Repository), "not-valid"})
+
+		workflows := map[int64]string{p.WorkflowID: p.WorkflowName}
+		if err := w.enforce(ctx, p, workflows, result, 0); err != nil {
+			w.runs.forget(p.RunID)
+			return err
+		}
+		w.cancelRun(ctx, p, result)
+
+		issue, err := w.reportWorkflowIssue(ctx, eventType, p, result)
+		if err != nil {
+			return err
+		}
+		w.attachIssue(p, workflows, issue.GetNumber())
+		return nil
+	}
+
+	w.clearViolation(p)
+	if err := w.resolveWorkflowIssue(ctx, p, result); err != nil {
+		w.logger.Errorw("error closing issue", "organization", p.Organization, "repository", p.Repository, "workflow_id", p.WorkflowID, "error", err)
+	}
+	if err := w.resolveCheckRun(ctx, p.Organization, p.Repository, p.WorkflowID); err != nil {
+		w.logger.Errorw("error resolving check run", "organization", p.Organization, "repository", p.Repository, "workflow_id", p.WorkflowID, "error", err)
 	}
 	return nil
 }
 
-func (w *WorkflowAction) generateWorkflowMessage(eventType string, p *WorkflowActionParams) (string, error) {
-	var message string
-	switch eventType {
-	case "run":
-		message = fmt.Sprintf(utils.WorkflowRunMessage,
-			p.Organization,
-			p.Repository,
-			w.client.ServerInfo().EnterpriseURL,
-			p.Organization,
-			p.Repository,
-			p.Sender,
-			p.Organization,
-			p.Repository,
-			p.WorkflowName,
-			p.WorkflowID)
-
-	case "dispatch":
-		message = fmt.Sprintf(utils.WorkflowDispatchMessage,
-			p.Organization,
-			p.Repository,
-			w.client.ServerInfo().EnterpriseURL,
-			p.Organization,
-			p.Repository,
-			p.Sender,
-			p.Organization,
-			p.Repository,
-			p.WorkflowName,
-			p.WorkflowID)
-	case "job":
-		message = fmt.Sprintf(utils.WorkflowJobMessage,
-			p.Organization,
-			p.Repository,
-			w.client.ServerInfo().EnterpriseURL,
-			p.Organization,
-			p.Repository,
-			p.Sender,
-			p.Organization,
-			p.Repository,
-			p.WorkflowName,
-			p.WorkflowID)
-	default:
-		return "", errors.New("unsupported event type")
-	}
-	return message, nil
+func (w *WorkflowAction) recordAudit(p *WorkflowActionParams, action string, result *ValidationResult) {
+	err := w.audit.Record(audit.Entry{
+		Organization: p.Organization,
+		Repository:   p.Repository,
+		WorkflowName: p.WorkflowName,
+		WorkflowID:   p.WorkflowID,
+		Event:        string(p.WebhookEvent),
+		Sender:       p.Sender,
+		Action:       action,
+		Mode:         w.enforcementMode(p.Organization),
+		Valid:        result.Valid(),
+		Result:       result,
+	})
+	if err != nil {
+		w.logger.Errorw(utils.LoggerErrorRecordingAudit, "error", err)
+	}
+	w.notify(action, p, result)
+}
+
+func (w *WorkflowAction) repoAction() *RepoAction {
+	return &RepoAction{
+		logger:                 w.logger,
+		client:                 w.client,
+		validationOrganization: w.organization,
+		validationRepository:   w.repository,
+		filesPath:              w.filesPath,
+		workerPoolSize:         w.workerPoolSize,
+		assignees:              w.assignees,
+		expiryGracePeriod:      w.expiry.GracePeriod,
+		contactVerifier:        w.verifier,
+		contactFailure:         w.contact.Failure,
+		enterpriseURL:          w.client.ServerInfo().EnterpriseURL,
+		sources:                w.sources,
+		policies:               w.policies,
+	}
 }
 
 func (w *WorkflowAction) disableWorkflowByOrganization(ctx context.Context, p *WorkflowActionParams) error {
 	enabledRepositories := "none"
 
-	c := config.Client{
-		GithubAuthConfig: w.client.GetConfig(),
-		Name:             "disable-workflow",
-		OrganizationName: p.Organization,
-		RepositoryName:   p.Repository,
-		ServerInfo:       w.client.ServerInfo(),
-	}
-	configClients := []config.Client{c}
-	workflowClients, err := clients.InitClients(w.logger, configClients)
+	workflowClient, err := w.client.ForRepository(p.Organization, p.Repository)
 	if err != nil {
 		return err
 	}
 
-	for _, workflowClient := range workflowClients {
-		_, resp, workflowErr := workflowClient.GetV3Client().Organizations.EditActionsPermissions(ctx, p.Organization, github.ActionsPermissions{
-			EnabledRepositories: &enabledRepositories,
-		})
-		if workflowErr != nil {
-			return workflowErr
-		}
+	_, resp, err := workflowClient.GetV3Client().Organizations.EditActionsPermissions(ctx, p.Organization, github.ActionsPermissions{
+		EnabledRepositories: &enabledRepositories,
+	})
+	if err != nil {
+		return err
+	}
 
-		if resp.StatusCode != http.StatusNoContent {
-			return errors.New(strconv.Itoa(resp.StatusCode))
-		}
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New(strconv.Itoa(resp.StatusCode))
 	}
 
 	return nil
 }
 
 func (w *WorkflowAction) disableWorkflowForRepo(ctx context.Context, p *WorkflowActionParams, repoID int64) error {
-	c := config.Client{
-		GithubAuthConfig: w.client.GetConfig(),
-		Name:             "disable-workflow",
-		OrganizationName: p.Organization,
-		RepositoryName:   p.Repository,
-		ServerInfo:       w.client.ServerInfo(),
-	}
-	configClients := []config.Client{c}
-	workflowClients, err := clients.InitClients(w.logger, configClients)
+	workflowClient, err := w.client.ForRepository(p.Organization, p.Repository)
 	if err != nil {
 		return err
 	}
 
-	for _, workflowClient := range workflowClients {
-		resp, workflowErr := workflowClient.GetV3Client().Actions.RemoveEnabledRepoInOrg(ctx, p.Organization, repoID)
-		if workflowErr != nil {
-			return workflowErr
-		}
+	resp, err := workflowClient.GetV3Client().Actions.RemoveEnabledRepoInOrg(ctx, p.Organization, repoID)
+	if err != nil {
+		return err
+	}
 
-		if resp.StatusCode != http.StatusNoContent {
-			return errors.New(strconv.Itoa(resp.StatusCode))
-		}
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New(strconv.Itoa(resp.StatusCode))
 	}
 
 	return nil
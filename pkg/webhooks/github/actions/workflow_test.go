@@ -15,6 +15,7 @@ func TestNewWorkflowAction(t *testing.T) {
 		logger    *zap.SugaredLogger
 		client    *clients.Github
 		rawConfig map[string]any
+		deps      *Dependencies
 	}
 	var tests []struct {
 		name    string
@@ -24,7 +25,7 @@ func TestNewWorkflowAction(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewWorkflowAction(tt.args.logger, tt.args.client, tt.args.rawConfig)
+			got, err := NewWorkflowAction(tt.args.logger, tt.args.client, tt.args.rawConfig, tt.args.deps)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewWorkflowAction() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -7,6 +7,7 @@ import (
 
 	"github.tools.sap/actions-rollout-app/config"
 	"github.tools.sap/actions-rollout-app/pkg/clients"
+	"github.tools.sap/actions-rollout-app/pkg/scheduler"
 	"github.tools.sap/actions-rollout-app/pkg/webhooks/github/actions"
 
 	ghwebhooks "github.com/go-playground/webhooks/v6/github"
@@ -28,13 +29,13 @@ type Webhook struct {
 }
 
 // NewGithubWebhook returns a new webhook controller
-func NewGithubWebhook(logger *zap.SugaredLogger, w config.Webhook, cs clients.ClientMap) (*Webhook, error) {
+func NewGithubWebhook(logger *zap.SugaredLogger, w config.Webhook, cs clients.ClientMap, deps *actions.Dependencies) (*Webhook, error) {
 	hook, err := ghwebhooks.New(ghwebhooks.Options.Secret(os.Getenv(w.Secret)))
 	if err != nil {
 		return nil, err
 	}
 
-	a, err := actions.InitActions(logger, cs, w.Actions)
+	a, err := actions.InitActions(logger, cs, w.Actions, deps)
 
 	if err != nil {
 		return nil, err
@@ -50,6 +51,11 @@ func NewGithubWebhook(logger *zap.SugaredLogger, w config.Webhook, cs clients.Cl
 	return controller, nil
 }
 
+// Jobs returns the scheduled jobs of the webhook actions
+func (w *Webhook) Jobs() []scheduler.Job {
+	return w.a.Jobs()
+}
+
 // Handle handles GitHub webhook events
 func (w *Webhook) Handle(response http.ResponseWriter, request *http.Request) {
 	payload, err := w.hook.Parse(request, listenEvents...)
@@ -16,6 +16,7 @@ func TestNewGithubWebhook(t *testing.T) {
 		logger *zap.SugaredLogger
 		w      config.Webhook
 		cs     clients.ClientMap
+		deps   *actions.Dependencies
 	}
 	var tests []struct {
 		name    string
@@ -25,7 +26,7 @@ func TestNewGithubWebhook(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewGithubWebhook(tt.args.logger, tt.args.w, tt.args.cs)
+			got, err := NewGithubWebhook(tt.args.logger, tt.args.w, tt.args.cs, tt.args.deps)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewGithubWebhook() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -4,6 +4,8 @@ import "time"
 
 const (
 	WebhookHandleTimeout                     = 240 * time.Second
+	ScheduledJobTimeout                      = 10 * time.Minute
+	RegistrationExpiryLayout                 = "2006-01-02"
 	ErrClientNotFound                        = "webhook action client not found: %s"
 	ErrUnsupportedType                       = "handler type not supported: %s"
 	ErrInvalidClient                         = "action %s only supports github clients, not: %s"
@@ -18,49 +20,238 @@ const (
 	ErrInvalidContactEmail                   = "invalid contact email or empty"
 	ErrInvalidUseCase                        = "invalid use case or empty"
 	ErrValidationEmptyContent                = "content is empty or nil"
+	ErrInvalidExpiryDate                     = "invalid expiry date, expected YYYY-MM-DD"
+	ErrRegistrationExpired                   = "registration expired"
+	ErrInvalidActionArg                      = "invalid action argument %s: %w"
+	ErrNoRegistrationFound                   = "no valid files found in repository %s/%s"
+	ErrRegistrationInvalid                   = "repository %s/%s is not valid: %s"
+	ErrUnknownRegistrationSource             = "unknown registration source %q, expected central or repository"
+	ErrMissingCountersignature               = "in-repo registration is not countersigned in the central repository"
+	ErrInvalidPolicy                         = "invalid policy %s: %v"
+	ErrEvaluatingPolicy                      = "error evaluating policy %s: %w"
+	ErrInvalidEnforcementMode                = "invalid enforcement mode %q, expected enforce or audit"
+	ErrInvalidEscalationStage                = "invalid escalation stage %d: %s"
+	ErrContactNotOrgMember                   = "contact is not an active member or team of the organization"
+	ErrContactEmailUnverified                = "contact email is not mapped to a member by a SAML identity or a verified domain email"
+	ErrInvalidContactFailure                 = "invalid contact verification failure mode %q, expected soft or hard"
+	ContactFailureSoft                       = "soft"
+	ContactFailureHard                       = "hard"
+	DefaultContactCacheTTL                   = time.Hour
 	ActionWorkflowHandler                    = "workflow-handling"
 	ActionRepoHandler                        = "repo-handling"
+	ActionAllowedActionsHandler              = "allowed-actions"
 	DefaultLocalRef                          = "refs/heads"
 	LoggerDebugInitWebhookAction             = "initialized github webhook action"
 	LoggerErrorCreatingWorkflowDispatch      = "error in workflow dispatch handler action"
 	LoggerErrorProcessingEvent               = "error processing event"
 	LoggerErrorCreatingWorkflowJob           = "error in workflow Job handler action"
 	LoggerErrorCreatingWorkflowRun           = "error in workflow Run handler action"
-	WorkflowRunMessage                       = `
-<a href='link' target="_blank"><img alt='Workflow status' src='https://img.shields.io/badge/Workflow_status - Disabled-100000?style=flat-square&logo=Workflow status&logoColor=white&labelColor=CD1111&color=E73B3B'/></a>
-
+	LoggerErrorRecordingAudit                = "error recording audit entry"
+	LoggerWarnInvalidJob                     = "skipping scheduled job without interval or run function"
+	LoggerDebugRunningJob                    = "running scheduled job"
+	LoggerErrorRunningJob                    = "error running scheduled job"
+	RegistrationSourceCentral                = "central"
+	RegistrationSourceRepository             = "repository"
+	DefaultRepositoryRegistrationPath        = ".github/actions-registration.yml"
+	DefaultCountersignaturePath              = "countersignatures.yml"
+	EnforcementModeEnforce                   = "enforce"
+	EnforcementModeAudit                     = "audit"
+	EnforcementScopeWorkflow                 = "workflow"
+	EnforcementScopeRepository               = "repository"
+	EnforcementScopeOrganization             = "organization"
+	ErrInvalidEnforcementScope               = "invalid enforcement scope %s"
+	ErrOrganizationScopeNotConfirmed         = "enforcement scope organization requires confirm_organization"
+	AuditActionValidated                     = "validated"
+	AuditActionDisabled                      = "disabled"
+	AuditActionRepositoryDisabled            = "repository-disabled"
+	AuditActionOrganizationDisabled          = "organization-disabled"
+	AuditActionWouldDisable                  = "would-disable"
+	AuditActionNotified                      = "notified"
+	AuditActionIssueCreated                  = "issue-created"
+	AuditActionIssueClosed                   = "issue-closed"
+	StateBucketOpenIssues                    = "open-issues"
+	DefaultIssueResolutionInterval           = time.Hour
+	DefaultIssueLabel                        = "${{ org_name }}/${{ repo_name }}"
+	DefaultLabelColor                        = "ededed"
+	LabelNotValidColor                       = "d73a4a"
+	LabelResolvedColor                       = "0e8a16"
+	GithubErrorAlreadyExists                 = "already_exists"
+	MaxLabelLength                           = 50
+	ErrInvalidIssueLabel                     = "invalid issue label %q: %s"
+	LabelNotValid                            = "not-valid"
+	LabelResolved                            = "resolved"
+	IssueResolvedComment                     = ":white_check_mark: %s/%s is valid again%s, closing this issue."
+	AuditActionNotificationFailed            = "notification-failed"
+	IssueTargetCentral                       = "central"
+	IssueTargetRepository                    = "repository"
+	IssueTargetBoth                          = "both"
+	ErrInvalidIssueTarget                    = "invalid issue target %q, expected central, repository or both"
+	ErrInvalidIssueRepository                = "invalid issue repository %q, expected owner/name"
+	IssueTrackedIn                           = "\n\n:link: Tracked in %s/%s#%d"
+	StateBucketDigests                       = "digests"
+	NotificationKindDigest                   = "digest"
+	DigestDeliveryIssue                      = "issue"
+	DigestDeliveryNotification               = "notification"
+	DefaultDigestInterval                    = 24 * time.Hour
+	DefaultDigestExpiringDays                = 14
+	ErrInvalidDigestDelivery                 = "invalid digest delivery %q, expected issue or notification"
+	ErrInvalidDigestRepository               = "invalid digest repository %q, expected a repository name without owner"
+	ErrDigestWithoutAudit                    = "digest requires the audit log, set audit.path"
+	DigestTitle                              = "[digest] %s"
+	DigestMarker                             = "<!-- actions-controller:digest %s -->"
+	DigestText                               = "%d new violations, %d disabled workflows, %d remediations and %d expiring registrations in %s"
+	LabelDigest                              = "digest"
+	LabelDigestColor                         = "1d76db"
+	AssigneeSourceRule                       = "rule"
+	AssigneeSourceRegistration               = "registration"
+	AssigneeSourceCodeowners                 = "codeowners"
+	AssigneeSourceOrgAdmins                  = "org_admins"
+	AssigneeSourceDefault                    = "default"
+	ErrInvalidAssigneeRule                   = "invalid assignee rule %d: %s"
+	ErrUnknownAssigneeSource                 = "unknown assignee source %q, expected registration, codeowners, org_admins or default"
+	MaxIssueAssignees                        = 10
+	DefaultAssigneeCacheTTL                  = time.Hour
+	StateBucketCheckRuns                     = "check-runs"
+	DefaultCheckRunName                      = "actions-controller"
+	CheckRunConclusionFailure                = "failure"
+	CheckRunConclusionActionRequired         = "action_required"
+	CheckRunConclusionSuccess                = "success"
+	MaxCheckRunSummaryLength                 = 65535
+	ErrInvalidCheckRunConclusion             = "invalid check run conclusion %q, expected failure or action_required"
+	CheckRunBlockedTitle                     = "Workflow %s is blocked"
+	CheckRunBlockedSummary                   = ":no_entry: The Actions Controller blocked workflow `%s` of %s/%s: %s\n\nRegister the repository as described in the [registration instructions](%s), the workflow is unblocked once the registration is valid."
+	CheckRunResolvedTitle                    = "%s/%s is valid again"
+	CheckRunResolvedSummary                  = ":white_check_mark: %s/%s is valid again, workflow `%s` is no longer blocked."
+	CheckRunConclusionNeutral                = "neutral"
+	CheckRunSupersededTitle                  = "Superseded by %s"
+	CheckRunSupersededSummary                = "Workflow `%s` is blocked on the newer commit %s, see the check run there."
+	NotifierWebhook                          = "webhook"
+	NotifierSlack                            = "slack"
+	NotifierEmail                            = "email"
+	NotificationKindRenewalReminder          = "renewal-reminder"
+	DefaultSMTPPort                          = 587
+	DefaultEmailRateLimit                    = 5
+	DefaultEmailRateWindow                   = 24 * time.Hour
+	ErrInvalidEmailNotifications             = "invalid email notifications: %s"
+	ErrSMTPStartTLSUnsupported               = "smtp server %s does not support STARTTLS"
+	DefaultEmailSubjectTemplate              = "[Actions Controller] {{ .Title }}"
+	NotifierTeams                            = "teams"
+	DefaultNotificationTimeout               = 10 * time.Second
+	DefaultNotificationBackoff               = time.Second
+	ErrInvalidNotificationTarget             = "invalid notification target %s: %s"
+	ErrInvalidNotificationRoute              = "invalid notification route %d: %s"
+	ErrInvalidNotificationSetting            = "invalid notification %s %v"
+	NotificationTitle                        = "[%s] %s/%s workflow %s"
+	ErrNotificationStatus                    = "notification to %s failed with status %d"
+	AuditActionReenabled                     = "re-enabled"
+	AuditActionCancelled                     = "cancelled"
+	AuditActionExempted                      = "exempted"
+	AuditActionAllowedActionsReverted        = "allowed-actions-reverted"
+	AuditActionAllowedActionsWouldRevert     = "allowed-actions-would-revert"
+	ActionsPermissionSelected                = "selected"
+	AuditActionCircuitBreakerTripped         = "circuit-breaker-tripped"
+	AuditActionCircuitBreakerReset           = "circuit-breaker-reset"
+	StateBucketCircuitBreaker                = "circuit-breaker"
+	CircuitBreakerGlobal                     = "*"
+	DefaultCircuitBreakerWindow              = time.Hour
+	ErrInvalidCircuitBreakerWindow           = "invalid circuit breaker window %s: %w"
+	ErrCircuitBreakerOpen                    = "circuit breaker open for %s"
+	CircuitBreakerTrippedTitle               = "[circuit-breaker] enforcement paused for %s"
+	CircuitBreakerTrippedMessage             = "The circuit breaker tripped after %d enforcement actions within %s for **%s**. Enforcement runs in audit mode until an admin resets the breaker."
+	LabelCircuitBreaker                      = "circuit-breaker"
+	AnalysisRuleUnpinnedAction               = "unpinned-action"
+	AnalysisRulePullRequestTargetCheckout    = "pull-request-target-checkout"
+	AnalysisRuleWriteAllPermissions          = "write-all-permissions"
+	AnalysisRuleSecretsInherit               = "secrets-inherit"
+	ErrUnknownAnalysisRule                   = "unknown workflow analysis rule %s"
+	ErrParsingWorkflow                       = "error parsing workflow %s: %w"
+	ErrInvalidIssueTemplate                  = "invalid issue %s template: %w"
+	ErrIssueTemplateSource                   = "issue %s template: set either %s or %s"
+	ErrUnsupportedEventType                  = "unsupported event type"
+	ErrWorkflowFindings                      = "workflow of %s/%s is not valid: %s"
+	AuditActionRunnerGroupAdded              = "runner-group-added"
+	AuditActionRunnerGroupRemoved            = "runner-group-removed"
+	AuditActionRunnerGroupWouldAdd           = "runner-group-would-add"
+	AuditActionRunnerGroupWouldRemove        = "runner-group-would-remove"
+	ErrRunnerGroupValidation                 = "runner groups not synced, %s/%s could not be validated: %s"
+	ErrRegistrationUnparsable                = "registration %s cannot be parsed: %v"
+	DefaultRunnerGroupsInterval              = time.Hour
+	RunnerGroupVisibilitySelected            = "selected"
+	DefaultAllowedActionsInterval            = time.Hour
+	DefaultAllowedActionsEventInterval       = 5 * time.Minute
+	ErrInvalidActionArgs                     = "invalid action arguments: %w"
+	ErrInvalidAllowedActionsPolicy           = "invalid allowed actions policy %d: %s"
+	AuditActionForceCancelled                = "force-cancelled"
+	WorkflowRunActionRequested               = "requested"
+	WorkflowRunActionInProgress              = "in_progress"
+	WorkflowRunStatusCompleted               = "completed"
+	EscalationNotify                         = "notify"
+	EscalationIssue                          = "issue"
+	EscalationDisable                        = "disable"
+	StateBucketViolations                    = "violations"
+	StateBucketDisabledWorkflows             = "disabled-workflows"
+	DefaultRemediationInterval               = time.Hour
+	IssueMarker                              = "<!-- actions-controller:workflow %s/%s/%d -->"
+	IssueOccurrences                         = "**Occurrences:** %d"
+	IssueOccurrenceComment                   = ":repeat: Workflow [%s](%s) of %s/%s failed validation again on %s event, triggered by @%s. This is occurrence **%d**."
+	RemediationComment                       = "The registration of %s/%s is valid again, workflow `%s` (%d) was re-enabled."
+	DefaultEscalationInterval                = time.Hour
+	MaxViolationRunIDs                       = 100
+	DefaultRunDeliveryTTL                    = time.Hour
+	LabelRenewalReminder                     = "renewal-reminder"
+	LabelExemptionExpired                    = "exemption-expired"
+	ExemptionExpiredTitle                    = "[exemption] %s expired on %s"
+	DefaultExemptionInterval                 = 24 * time.Hour
+	DefaultExemptionReloadInterval           = 5 * time.Minute
+	StateBucketExemptionReminders            = "exemption-reminders"
+	ErrInvalidExemption                      = "invalid exemption %s: %s"
+	RegistrationRenewalText                  = "The registration %s expires on %s. Please renew it by updating the expires field, otherwise the workflows of the registered repositories will be disabled."
+	RegistrationRenewalTitle                 = "[renewal] %s expires on %s"
+	RegistrationRenewalMessage               = `
 ## Actions Controller
 
-<a href='#' target="#"><img alt='Workflow status' src='https://img.shields.io/badge/Workflow_status - Disabled-100000?style=for-the-badge&logo=Workflow status&logoColor=white&labelColor=CD1111&color=E73B3B'/></a>
-
-:rocket: Actions Controller bot has detected changes in [%s/%s](%s/%s/%s) on Workflow Run event
+:hourglass: The registration [%s](%s/%s/%s/blob/main/%s) expires on **%s**.
 
-### :red_circle: Details
-| Sender         | Organization       | Repository            | Workflow Name | Workflow ID |
-| ---------------|---------------------|-----------------------|---------------|-------------|
-| @%s      | %s     | %s    | %s            | %d         |`
-	WorkflowDispatchMessage = `
-<a href='link' target="_blank"><img alt='Workflow status' src='https://img.shields.io/badge/Workflow_status - Disabled-100000?style=flat-square&logo=Workflow status&logoColor=white&labelColor=CD1111&color=E73B3B'/></a>
+Please renew it by updating the ` + "`expires`" + ` field, otherwise workflows of the registered repositories will be disabled once the grace period has passed.
 
+### :information_source: Details
+| Owner         | Contact       | Use Case      |
+| --------------|---------------|---------------|
+| @%s      | %s     | %s    |`
+	ExemptionExpiredMessage = `
 ## Actions Controller
 
-:rocket: Actions Controller bot has detected changes in [%s/%s](%s/%s/%s)  on Workflow Dispatch event
+:hourglass: The exemption for **%s** expired on **%s**, its workflows are enforced again.
 
-### :red_circle: Details
-| Sender         | Organization       | Repository            | Workflow Name | Workflow ID |
-| ---------------|---------------------|-----------------------|---------------|-------------|
-| @%s      | %s     | %s    | %s            | %d         |`
-	WorkflowJobMessage = `
-<a href='link' target="_blank"><img alt='Workflow status' src='https://img.shields.io/badge/Workflow_status - Disabled-100000?style=flat-square&logo=Workflow status&logoColor=white&labelColor=CD1111&color=E73B3B'/></a>
+Remove the exemption or extend its ` + "`expires`" + ` field if it is still needed.
 
+### :information_source: Details
+| Approver      | Reason        |
+| --------------|---------------|
+| @%s      | %s     |`
+	DefaultIssueTitleTemplate = `[{{ .Event.WorkflowID }}] - {{ .Event.Organization }}/{{ .Event.Repository }}`
+	DefaultIssueBodyTemplate  = `
 ## Actions Controller
 
-<a href='link' target="_blank"><img alt='Workflow status' src='https://img.shields.io/badge/Workflow_status - Disabled-100000?style=flat-square&logo=Workflow status&logoColor=white&labelColor=CD1111&color=E73B3B'/></a>
+<a href='{{ .Links.Run }}' target="_blank"><img alt='Workflow status' src='https://img.shields.io/badge/Workflow_status - Disabled-100000?style=for-the-badge&logo=Workflow status&logoColor=white&labelColor=CD1111&color=E73B3B'/></a>
 
-:rocket: Actions Controller bot has detected changes in [%s/%s](%s/%s/%s)  on Workflow Job event
+:rocket: Actions Controller bot has detected changes in [{{ .Event.Organization }}/{{ .Event.Repository }}]({{ .Links.Repository }}) on {{ .EventName }} event
 
 ### :red_circle: Details
 | Sender         | Organization       | Repository            | Workflow Name | Workflow ID |
 | ---------------|---------------------|-----------------------|---------------|-------------|
-| @%s      | %s     | %s    | %s            | %d         |`
+| @{{ .Event.Sender }}      | {{ .Event.Organization }}     | {{ .Event.Repository }}    | [{{ .Event.WorkflowName }}]({{ .Links.Workflow }})            | {{ .Event.WorkflowID }}         |
+{{- with .Validation }}{{ .Markdown }}{{ end }}`
+	DefaultEmailBodyTemplate = `Hello,
+
+the Actions Controller reports for {{ .Organization }}{{ with .Repository }}/{{ . }}{{ end }}, which is registered with you as contact:
+
+{{ .Title }}
+{{ with .Text }}
+{{ . }}
+{{ end }}{{ with .URL }}
+Details: {{ . }}
+{{ end }}
+This email was sent because your address is the contactEmail of the registration.
+`
 )